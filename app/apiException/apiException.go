@@ -25,10 +25,35 @@ var (
 	PictureError          = NewError(http.StatusInternalServerError, 200513, "仅允许上传图片文件")
 	PictureSizeError      = NewError(http.StatusInternalServerError, 200514, "图片大小超出限制")
 	NotSuperAdmin         = NewError(http.StatusInternalServerError, 200513, "很抱歉，您暂无权限注册账号")
-	NoPermission          = NewError(http.StatusInternalServerError, 200514, "很抱歉，您暂无权限操作")
+	NoPermission          = NewError(http.StatusForbidden, 200514, "很抱歉，您暂无权限操作")
 	SurveyNotExist        = NewError(http.StatusInternalServerError, 200515, "问卷不存在")
 	PermissionExist       = NewError(http.StatusInternalServerError, 200516, "该用户已有权限，请勿重复操作！")
 	PermissionBelong      = NewError(http.StatusInternalServerError, 200517, "问卷为该用户所有，无需操作！")
+	RequiredError         = NewError(http.StatusInternalServerError, 200518, "必填问题未填写！")
+	OptionError           = NewError(http.StatusInternalServerError, 200519, "所选选项不存在！")
+	QuestionNotMatch      = NewError(http.StatusInternalServerError, 200520, "问题与问卷不匹配！")
+	ImgAnswerError        = NewError(http.StatusInternalServerError, 200521, "图片答案无效！")
+	QuestionSkipped       = NewError(http.StatusInternalServerError, 200522, "该问题已被跳过，无需作答！")
+	SurveyNotOpen         = NewError(http.StatusInternalServerError, 200523, "问卷未发布，无法填写！")
+	SurveyNotStart        = NewError(http.StatusInternalServerError, 200524, "问卷尚未开始，请稍后再来！")
+	SurveyPaused          = NewError(http.StatusInternalServerError, 200525, "问卷已暂停收集！")
+	StatusTransitionError = NewError(http.StatusInternalServerError, 200526, "问卷当前状态不允许该操作！")
+	SubmitLimitError      = NewError(http.StatusInternalServerError, 200527, "您已提交过该问卷，请勿重复提交！")
+	DraftNotExist         = NewError(http.StatusInternalServerError, 200528, "草稿不存在或已过期")
+	TemplateNotExist      = NewError(http.StatusInternalServerError, 200529, "模板不存在")
+	ImportFileError       = NewError(http.StatusInternalServerError, 200530, "导入文件格式错误")
+	ResponseNotExist      = NewError(http.StatusInternalServerError, 200531, "答卷不存在")
+	CrosstabQuestionError = NewError(http.StatusInternalServerError, 200532, "交叉分析的题目须为选择题")
+	SurveyFullError       = NewError(http.StatusInternalServerError, 200533, "问卷名额已满")
+	OptionFullError       = NewError(http.StatusInternalServerError, 200534, "选项名额已满")
+	ScaleError            = NewError(http.StatusInternalServerError, 200535, "评分超出范围")
+	PermissionNotExist    = NewError(http.StatusInternalServerError, 200536, "该用户没有该问卷的权限")
+	TokenInvalid          = NewError(http.StatusUnauthorized, 200537, "令牌无效或已过期")
+	TokenScopeError       = NewError(http.StatusForbidden, 200538, "令牌未授权该操作")
+	TokenNotExist         = NewError(http.StatusInternalServerError, 200539, "令牌不存在")
+	IdentityRequired      = NewError(http.StatusInternalServerError, 200540, "该问卷需要验证身份后填写")
+	ImportFileSizeError   = NewError(http.StatusInternalServerError, 200541, "导入文件大小超出限制")
+	ExportExcelError      = NewError(http.StatusInternalServerError, 200542, "问卷包含Excel无法表示的设置，请导出为JSON或YAML")
 	NotInit               = NewError(http.StatusNotFound, 200404, http.StatusText(http.StatusNotFound))
 	NotFound              = NewError(http.StatusNotFound, 200404, http.StatusText(http.StatusNotFound))
 	Unknown               = NewError(http.StatusInternalServerError, 300500, "系统异常，请稍后重试!")
@@ -39,7 +39,13 @@ func Login(c *gin.Context) {
 			return
 		}
 	}
-	if user.Password != data.Password {
+	ok, err := adminService.CheckPassword(user, data.Password)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	if !ok {
 		c.Error(errors.New("密码错误"))
 		utils.JsonErrorResponse(c, apiException.NoThatPasswordOrWrong)
 		return
@@ -70,6 +76,12 @@ func Register(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
+	//bcrypt最多使用密码的前72字节，按字节而不是字符数限制
+	if len(data.Password) > 72 {
+		c.Error(errors.New("密码超过72字节"))
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
 	//判断是否有权限
 	adminKey := config.Config.GetInt("key")
 	if adminKey != data.Key {
@@ -88,7 +100,7 @@ func Register(c *gin.Context) {
 	err = adminService.CreateAdmin(models.User{
 		Username:  data.Username,
 		Password:  data.Password,
-		AdminType: 1,
+		AdminType: models.AdminTypeNormal,
 	})
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
@@ -0,0 +1,74 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+	"bytes"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取两道选择题的交叉分析
+type GetCrosstabData struct {
+	ID               int `form:"id" binding:"required"`
+	RowQuestionID    int `form:"row_question_id" binding:"required"`
+	ColumnQuestionID int `form:"column_question_id" binding:"required"`
+	adminService.AnswerFilter
+}
+
+func GetCrosstab(c *gin.Context) {
+	crosstab, ok := getCrosstab(c)
+	if !ok {
+		return
+	}
+	utils.JsonSuccessResponse(c, crosstab)
+}
+
+// 下载交叉分析表
+func DownloadCrosstab(c *gin.Context) {
+	crosstab, ok := getCrosstab(c)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	err := adminService.WriteCrosstabExcel(&buf, crosstab)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	fileName := url.PathEscape(crosstab.RowSubject + "-" + crosstab.ColumnSubject + ".xlsx")
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+fileName)
+	c.Data(http.StatusOK, adminService.ExportContentTypes[adminService.ExportXLSX], buf.Bytes())
+}
+
+// 校验参数和权限后计算交叉表，失败时已写入错误响应
+func getCrosstab(c *gin.Context) (adminService.Crosstab, bool) {
+	var data GetCrosstabData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return adminService.Crosstab{}, false
+	}
+	survey := midwares.GetSurvey(c)
+	crosstab, err := adminService.GetCrosstab(survey, data.RowQuestionID, data.ColumnQuestionID, data.AnswerFilter)
+	if err == adminService.ErrCrosstabQuestion {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.CrosstabQuestionError)
+		return adminService.Crosstab{}, false
+	} else if err == adminService.ErrInvalidFilter {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return adminService.Crosstab{}, false
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return adminService.Crosstab{}, false
+	}
+	return crosstab, true
+}
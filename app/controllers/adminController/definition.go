@@ -0,0 +1,161 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/models"
+	"QA-System/app/services/adminService"
+	"QA-System/app/services/sessionService"
+	"QA-System/app/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// 导入文件的大小上限
+const maxDefinitionSize = 5 << 20 // 5MB
+
+// 从文件导入问卷，支持json、yaml、xlsx格式
+func ImportSurvey(c *gin.Context) {
+	// 限制请求体大小，避免解析表单时读入过大的文件
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDefinitionSize+1<<20)
+	file, err := c.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ImportFileSizeError)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	//鉴权
+	user, err := sessionService.GetUserSession(c)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.NotLogin)
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	defer src.Close()
+	content, err := io.ReadAll(io.LimitReader(src, maxDefinitionSize+1))
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	if len(content) > maxDefinitionSize {
+		c.Error(errors.New("导入文件大小超出限制"))
+		utils.JsonErrorResponse(c, apiException.ImportFileSizeError)
+		return
+	}
+	//解析文件
+	var definition adminService.SurveyDefinition
+	definitionErrors := make([]adminService.DefinitionError, 0)
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".json":
+		definition, err = adminService.ParseDefinitionJSON(content)
+	case ".yaml", ".yml":
+		definition, err = adminService.ParseDefinitionYAML(content)
+	case ".xlsx":
+		definition, definitionErrors, err = adminService.ParseDefinitionExcel(bytes.NewReader(content))
+	default:
+		err = errors.New("不支持的文件格式")
+	}
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ImportFileError)
+		return
+	}
+	//校验问卷定义
+	if len(definitionErrors) == 0 {
+		definitionErrors = adminService.ValidateDefinition(definition)
+	}
+	if len(definitionErrors) > 0 {
+		c.Error(errors.New("问卷定义校验失败"))
+		utils.JsonErrorResponseWithData(c, apiException.ImportFileError, gin.H{"errors": definitionErrors})
+		return
+	}
+	//解析时间转换为中国时间(UTC+8)
+	ddlTime, err := time.Parse(time.RFC3339, definition.Time)
+	if err != nil {
+		c.Error(err)
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	ddlTime = ddlTime.Add(-8 * time.Hour)
+	//创建问卷
+	err = adminService.CreateSurvey(user.ID, definition.Title, definition.Desc, definition.Img, definition.Questions, models.SurveyDraft, nil, ddlTime)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, nil)
+}
+
+// 导出问卷定义
+type ExportSurveyData struct {
+	ID     int    `form:"id" binding:"required"`
+	Format string `form:"format" binding:"required,oneof=json yaml xlsx"`
+}
+
+func ExportSurvey(c *gin.Context) {
+	var data ExportSurveyData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	definition, err := adminService.GetSurveyDefinition(survey)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	//生成文件
+	var buf bytes.Buffer
+	var contentType string
+	switch data.Format {
+	case "json":
+		contentType = "application/json"
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(definition)
+	case "yaml":
+		contentType = "application/x-yaml"
+		err = yaml.NewEncoder(&buf).Encode(definition)
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = adminService.WriteDefinitionExcel(&buf, definition)
+	}
+	if errors.Is(err, adminService.ErrExcelUnsupported) {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ExportExcelError)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	fileName := url.PathEscape(survey.Title + "." + data.Format)
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+fileName)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,65 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 实时推送问卷的新答卷和选项统计(SSE)
+type GetLiveFeedData struct {
+	ID int `form:"id" binding:"required"`
+}
+
+func GetLiveFeed(c *gin.Context) {
+	var data GetLiveFeedData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	// 先订阅再获取统计，避免遗漏两者之间提交的答卷
+	events, unsubscribe, err := adminService.SubscribeLive(survey.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	defer unsubscribe()
+	statistics, err := adminService.GetSurveyStatistics(survey.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("statistics", statistics)
+	// 新答卷和统计数据由同一问卷的所有连接共享，空闲时定期发送心跳保持连接
+	ctx := c.Request.Context()
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Name, event.Data)
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+		}
+		return true
+	})
+}
@@ -3,7 +3,6 @@ package adminController
 import (
 	"QA-System/app/apiException"
 	"QA-System/app/services/adminService"
-	"QA-System/app/services/sessionService"
 	"QA-System/app/utils"
 
 	"github.com/gin-gonic/gin"
@@ -22,13 +21,6 @@ func GetLogMsg(c *gin.Context){
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	_, err = sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
-		return
-	}
 	response, err := adminService.GetLastLinesFromLogFile("app.log", data.Num, data.LogType)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
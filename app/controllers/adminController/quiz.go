@@ -0,0 +1,84 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 开启或关闭测验模式
+type UpdateSurveyQuizData struct {
+	ID       int  `json:"id" binding:"required"`
+	QuizMode bool `json:"quiz_mode"`
+}
+
+func UpdateSurveyQuiz(c *gin.Context) {
+	var data UpdateSurveyQuizData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	err = adminService.UpdateSurveyQuizMode(survey.ID, data.QuizMode)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, nil)
+}
+
+// 获取测验得分统计
+type GetQuizStatisticsData struct {
+	ID int `form:"id" binding:"required"`
+}
+
+func GetQuizStatistics(c *gin.Context) {
+	var data GetQuizStatisticsData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	statistics, err := adminService.GetQuizStatistics(survey)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, statistics)
+}
+
+// 获取测验得分排名
+type GetQuizRankingData struct {
+	ID    int `form:"id" binding:"required"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+func GetQuizRanking(c *gin.Context) {
+	var data GetQuizRankingData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	if data.Limit == 0 {
+		data.Limit = 50
+	}
+	survey := midwares.GetSurvey(c)
+	ranking, err := adminService.GetQuizRanking(survey, data.Limit)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{"ranking": ranking})
+}
@@ -0,0 +1,89 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+	"math"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分页获取答卷，每份答卷一条记录
+type GetResponsesData struct {
+	ID       int `form:"id" binding:"required"`
+	PageNum  int `form:"page_num" binding:"required"`
+	PageSize int `form:"page_size" binding:"required"`
+	adminService.AnswerFilter
+}
+
+func GetResponses(c *gin.Context) {
+	var data GetResponsesData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	responses, total, err := adminService.GetSurveyResponses(survey, data.PageNum, data.PageSize, data.AnswerFilter)
+	if err == adminService.ErrInvalidFilter {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{
+		"responses":      responses,
+		"total":          *total,
+		"total_page_num": math.Ceil(float64(*total) / float64(data.PageSize)),
+	})
+}
+
+// 获取单份答卷
+type ResponseData struct {
+	ID string `form:"id" binding:"required"`
+}
+
+func GetResponse(c *gin.Context) {
+	var data ResponseData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	answerSheet := midwares.GetAnswerSheet(c)
+	survey := midwares.GetSurvey(c)
+	response, err := adminService.GetResponse(survey, answerSheet)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, response)
+}
+
+// 删除单份答卷
+func DeleteResponse(c *gin.Context) {
+	var data ResponseData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	answerSheet := midwares.GetAnswerSheet(c)
+	survey := midwares.GetSurvey(c)
+	err = adminService.DeleteResponse(survey, answerSheet)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, nil)
+}
@@ -2,17 +2,19 @@ package adminController
 
 import (
 	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/models"
 	"QA-System/app/services/adminService"
-	"QA-System/app/services/sessionService"
 	"QA-System/app/utils"
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreatePermissionData struct {
 	UserName string `json:"username"`
-	SurveyID int    `json:"survey_id"`
+	Role     int    `json:"role" binding:"omitempty,oneof=1 2 3 4"` //协作者权限，默认为编辑问卷
 }
 
 func CreatrPermission(c *gin.Context) {
@@ -23,43 +25,69 @@ func CreatrPermission(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	admin, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
+	survey := midwares.GetSurvey(c)
+	if data.Role == 0 {
+		data.Role = models.PermissionEdit
+	}
+	// 只能授予低于自身的权限
+	if data.Role >= midwares.GetPermission(c) {
+		c.Error(errors.New("授予的权限不低于自身权限"))
+		utils.JsonErrorResponseWithStatus(c, apiException.NoPermission)
 		return
 	}
-	if admin.AdminType != 2 {
-		c.Error(errors.New("没有权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
+	user, ok := getCollaborator(c, data.UserName)
+	if !ok {
 		return
 	}
-	user ,err:= adminService.GetUserByName(data.UserName)
-	if err != nil {
+	err = adminService.CheckPermission(user.ID, survey.ID)
+	if err == nil {
+		c.Error(errors.New("权限已存在"))
+		utils.JsonErrorResponse(c, apiException.PermissionExist)
+		return
+	} else if err != gorm.ErrRecordNotFound {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	survey, err := adminService.GetSurveyByID(data.SurveyID)
+	//创建权限
+	err = adminService.CreatePermission(user.ID, survey.ID, data.Role)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	if survey.UserID == user.ID {
-		c.Error(errors.New("不能给问卷所有者添加权限"))
-		utils.JsonErrorResponse(c, apiException.PermissionBelong)
+	utils.JsonSuccessResponse(c, nil)
+}
+
+type UpdatePermissionData struct {
+	UserName string `json:"username"`
+	Role     int    `json:"role" binding:"required,oneof=1 2 3 4"`
+}
+
+// 修改协作者权限
+func UpdatePermission(c *gin.Context) {
+	var data UpdatePermissionData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	err =adminService.CheckPermission(user.ID, data.SurveyID)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.PermissionExist)
+	survey := midwares.GetSurvey(c)
+	if data.Role >= midwares.GetPermission(c) {
+		c.Error(errors.New("授予的权限不低于自身权限"))
+		utils.JsonErrorResponseWithStatus(c, apiException.NoPermission)
 		return
 	}
-	//创建权限
-	err = adminService.CreatePermission(user.ID, data.SurveyID)
+	user, ok := getCollaborator(c, data.UserName)
+	if !ok {
+		return
+	}
+	if !checkCollaboratorRole(c, user.ID, survey.ID) {
+		return
+	}
+	//修改权限
+	err = adminService.UpdatePermission(user.ID, survey.ID, data.Role)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
@@ -70,7 +98,6 @@ func CreatrPermission(c *gin.Context) {
 
 type DeletePermissionData struct {
 	UserName string `form:"username"`
-	SurveyID int    `form:"survey_id"`
 }
 
 func DeletePermission(c *gin.Context) {
@@ -81,41 +108,75 @@ func DeletePermission(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	admin, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
+	survey := midwares.GetSurvey(c)
+	user, ok := getCollaborator(c, data.UserName)
+	if !ok {
 		return
 	}
-	if admin.AdminType != 2 {
-		c.Error(errors.New("没有权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
+	if !checkCollaboratorRole(c, user.ID, survey.ID) {
 		return
 	}
-	user ,err:= adminService.GetUserByName(data.UserName)
+	//删除权限
+	err = adminService.DeletePermission(user.ID, survey.ID)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	survey, err := adminService.GetSurveyByID(data.SurveyID)
+	utils.JsonSuccessResponse(c, nil)
+}
+
+// 获取问卷协作者列表
+func GetPermissions(c *gin.Context) {
+	survey := midwares.GetSurvey(c)
+	collaborators, err := adminService.GetCollaborators(survey.ID)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	if survey.UserID==user.ID {
-		c.Error(errors.New("不能删除问卷所有者的权限"))
+	utils.JsonSuccessResponse(c, gin.H{
+		"owner_id":      survey.UserID,
+		"collaborators": collaborators,
+	})
+}
+
+// 获取要授权的管理员，不能是问卷所有者
+func getCollaborator(c *gin.Context, username string) (models.User, bool) {
+	user, err := adminService.GetUserByName(username)
+	if err == gorm.ErrRecordNotFound {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.UserNotFind)
+		return user, false
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return user, false
+	}
+	if midwares.GetSurvey(c).UserID == user.ID {
+		c.Error(errors.New("不能修改问卷所有者的权限"))
 		utils.JsonErrorResponse(c, apiException.PermissionBelong)
-		return
+		return user, false
 	}
-	//删除权限
-	err = adminService.DeletePermission(user.ID, data.SurveyID)
-	if err != nil {
+	return user, true
+}
+
+// 只能修改权限低于自身的协作者，管理协作者之间不能互相修改，只有问卷所有者和超级管理员可以修改
+func checkCollaboratorRole(c *gin.Context, uid int, surveyID int) bool {
+	manage, err := adminService.GetPermission(uid, surveyID)
+	if err == gorm.ErrRecordNotFound {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.PermissionNotExist)
+		return false
+	} else if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
+		return false
 	}
-	utils.JsonSuccessResponse(c, nil)
-}
\ No newline at end of file
+	if manage.Role >= midwares.GetPermission(c) {
+		c.Error(errors.New("协作者权限不低于自身权限"))
+		utils.JsonErrorResponseWithStatus(c, apiException.NoPermission)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取问卷统计数据
+type GetSurveyStatisticsData struct {
+	ID int `form:"id" binding:"required"`
+}
+
+func GetSurveyStatistics(c *gin.Context) {
+	var data GetSurveyStatisticsData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	//统计问卷数据
+	statistics, err := adminService.GetSurveyStatistics(survey.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, statistics)
+}
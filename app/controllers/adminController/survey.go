@@ -2,20 +2,18 @@ package adminController
 
 import (
 	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/models"
 	"QA-System/app/services/adminService"
 	"QA-System/app/services/sessionService"
 	"QA-System/app/services/userService"
 	"QA-System/app/utils"
-	"QA-System/config/config"
 	"errors"
-	"fmt"
 	"math"
-	"os"
-	"strconv"
+	"net/http"
+	"net/url"
 	"time"
 
-	"github.com/xuri/excelize/v2"
-	"gorm.io/gorm"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,7 +23,8 @@ type CreateSurveyData struct {
 	Title     string                  `json:"title"`
 	Desc      string                  `json:"desc" `
 	Img       string                  `json:"img" `
-	Status    int                     `json:"status" `
+	Status    int                     `json:"status" binding:"omitempty,oneof=1 2 3"` //不填时为未发布
+	StartTime string                  `json:"start_time"`
 	Time      string                  `json:"time"`
 	Questions []adminService.Question `json:"questions"`
 }
@@ -38,6 +37,9 @@ func CreateSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
+	if data.Status == 0 {
+		data.Status = models.SurveyDraft
+	}
 	//鉴权
 	user, err := sessionService.GetUserSession(c)
 	if err != nil {
@@ -53,8 +55,32 @@ func CreateSurvey(c *gin.Context) {
 		return
 	}
 	ddlTime = ddlTime.Add(-8 * time.Hour)
+	//定时发布的问卷需要设置开始时间
+	var startTime *time.Time
+	if data.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, data.StartTime)
+		if err != nil {
+			c.Error(err)
+			utils.JsonErrorResponse(c, apiException.ParamError)
+			return
+		}
+		t = t.Add(-8 * time.Hour)
+		startTime = &t
+	}
+	if data.Status == models.SurveyScheduled && startTime == nil {
+		c.Error(errors.New("未设置开始时间"))
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	//检查问题设置
+	err = adminService.CheckQuestions(data.Questions)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
 	//创建问卷
-	err = adminService.CreateSurvey(user.ID, data.Title, data.Desc, data.Img, data.Questions, data.Status, ddlTime)
+	err = adminService.CreateSurvey(user.ID, data.Title, data.Desc, data.Img, data.Questions, data.Status, startTime, ddlTime)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
@@ -65,8 +91,9 @@ func CreateSurvey(c *gin.Context) {
 
 // 修改问卷状态
 type UpdateSurveyStatusData struct {
-	ID     int `json:"id" binding:"required"`
-	Status int `json:"status" binding:"required,oneof=1 2"`
+	ID        int    `json:"id" binding:"required"`
+	Status    int    `json:"status" binding:"required,oneof=1 2 3 4 5 6"`
+	StartTime string `json:"start_time"` //状态改为待开始时的开始时间
 }
 
 func UpdateSurveyStatus(c *gin.Context) {
@@ -77,34 +104,98 @@ func UpdateSurveyStatus(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	user, err := sessionService.GetUserSession(c)
+	survey := midwares.GetSurvey(c)
+	//判断问卷状态
+	if survey.Status == data.Status {
+		c.Error(errors.New("问卷状态重复"))
+		utils.JsonErrorResponse(c, apiException.StatusRepeatError)
+		return
+	}
+	if !adminService.CanTransitStatus(survey.Status, data.Status) {
+		c.Error(errors.New("问卷状态转换不合法"))
+		utils.JsonErrorResponse(c, apiException.StatusTransitionError)
+		return
+	}
+	//重新发布时不能已过截止时间
+	if data.Status == models.SurveyOpen && !survey.Deadline.IsZero() && survey.Deadline.Before(time.Now()) {
+		c.Error(errors.New("问卷已过截止时间"))
+		utils.JsonErrorResponse(c, apiException.TimeBeyondError)
+		return
+	}
+	//定时发布需要设置晚于当前的开始时间
+	if data.Status == models.SurveyScheduled {
+		startTime, err := time.Parse(time.RFC3339, data.StartTime)
+		if err != nil {
+			c.Error(err)
+			utils.JsonErrorResponse(c, apiException.ParamError)
+			return
+		}
+		startTime = startTime.Add(-8 * time.Hour)
+		if startTime.Before(time.Now()) {
+			c.Error(errors.New("开始时间早于当前时间"))
+			utils.JsonErrorResponse(c, apiException.ParamError)
+			return
+		}
+		err = adminService.UpdateSurveyStartTime(survey.ID, startTime)
+		if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.ServerError)
+			return
+		}
+	}
+	//修改问卷状态
+	err = adminService.UpdateSurveyStatus(survey.ID, data.Status)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
+		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	// 获取问卷
-	survey, err := adminService.GetSurveyByID(data.ID)
+	utils.JsonSuccessResponse(c, nil)
+}
+
+// 修改问卷提交限制
+type UpdateSurveyLimitData struct {
+	ID           int `json:"id" binding:"required"`
+	SubmitLimit  int `json:"submit_limit" binding:"oneof=0 1 2 3"`
+	LimitWindow  int `json:"limit_window" binding:"min=0"`
+	MaxResponses int `json:"max_responses" binding:"min=0"`
+}
+
+func UpdateSurveyLimit(c *gin.Context) {
+	var data UpdateSurveyLimitData
+	err := c.ShouldBindJSON(&data)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
+		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//判断权限
-	if (user.AdminType != 2) && (user.AdminType != 1 || survey.UserID != user.ID) && !adminService.UserInManage(user.ID, survey.ID) {
-		c.Error(errors.New("无权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
+	survey := midwares.GetSurvey(c)
+	//修改提交限制
+	err = adminService.UpdateSurveyLimit(survey.ID, data.SubmitLimit, data.LimitWindow, data.MaxResponses)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	//判断问卷状态
-	if survey.Status == data.Status {
-		c.Error(errors.New("问卷状态重复"))
-		utils.JsonErrorResponse(c, apiException.StatusRepeatError)
+	utils.JsonSuccessResponse(c, nil)
+}
+
+// 设置是否打乱题目顺序
+type UpdateSurveyShuffleData struct {
+	ID               int  `json:"id" binding:"required"`
+	ShuffleQuestions bool `json:"shuffle_questions"`
+}
+
+func UpdateSurveyShuffle(c *gin.Context) {
+	var data UpdateSurveyShuffleData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//修改问卷状态
-	err = adminService.UpdateSurveyStatus(data.ID, data.Status)
+	survey := midwares.GetSurvey(c)
+	err = adminService.UpdateSurveyShuffle(survey.ID, data.ShuffleQuestions)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
@@ -130,38 +221,6 @@ func UpdateSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	user, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
-		return
-	}
-	// 获取问卷
-	survey, err := adminService.GetSurveyByID(data.ID)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	//判断权限
-	if (user.AdminType != 2) && (user.AdminType != 1 || survey.UserID != user.ID) && !adminService.UserInManage(user.ID, survey.ID) {
-		c.Error(errors.New("无权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
-		return
-	}
-	//判断问卷状态
-	if survey.Status != 1 {
-		c.Error(errors.New("问卷状态不为未发布"))
-		utils.JsonErrorResponse(c, apiException.StatusRepeatError)
-		return
-	}
-	// 判断问卷的填写数量是否为零
-	if survey.Num != 0 {
-		c.Error(errors.New("问卷已有填写数量"))
-		utils.JsonErrorResponse(c, apiException.SurveyNumError)
-		return
-	}
 	//解析时间转换为中国时间(UTC+8)
 	ddlTime, err := time.Parse(time.RFC3339, data.Time)
 	if err != nil {
@@ -170,8 +229,16 @@ func UpdateSurvey(c *gin.Context) {
 		return
 	}
 	ddlTime = ddlTime.Add(-8 * time.Hour)
-	//修改问卷
-	err = adminService.UpdateSurvey(data.ID, data.Title, data.Desc, data.Img, data.Questions,ddlTime)
+	//检查问题设置
+	err = adminService.CheckQuestions(data.Questions)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	//修改问卷，已有答卷时生成新版本
+	err = adminService.UpdateSurvey(survey.ID, data.Title, data.Desc, data.Img, data.Questions,ddlTime)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
@@ -193,32 +260,9 @@ func DeleteSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	user, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
-		return
-	}
-	// 获取问卷
-	survey, err := adminService.GetSurveyByID(data.ID)
-	if err == gorm.ErrRecordNotFound {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.SurveyNotExist)
-		return
-	} else if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	//判断权限
-	if (user.AdminType != 2) && (user.AdminType != 1 || survey.UserID != user.ID) && !adminService.UserInManage(user.ID, survey.ID) {
-		c.Error(errors.New("无权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
-		return
-	}
+	survey := midwares.GetSurvey(c)
 	//删除问卷
-	err = adminService.DeleteSurvey(data.ID)
+	err = adminService.DeleteSurvey(survey.ID)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
@@ -232,6 +276,7 @@ type GetSurveyAnswersData struct {
 	ID       int `form:"id" binding:"required"`
 	PageNum  int `form:"page_num" binding:"required"`
 	PageSize int `form:"page_size" binding:"required"`
+	adminService.AnswerFilter
 }
 
 func GetSurveyAnswers(c *gin.Context) {
@@ -242,34 +287,15 @@ func GetSurveyAnswers(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	//鉴权
-	user, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
-		return
-	}
-	// 获取问卷
-	survey, err := adminService.GetSurveyByID(data.ID)
-	if err == gorm.ErrRecordNotFound {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.SurveyNotExist)
-		return
-	} else if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	//判断权限
-	if (user.AdminType != 2) && (user.AdminType != 1 || survey.UserID != user.ID) && !adminService.UserInManage(user.ID, survey.ID) {
-		c.Error(errors.New("无权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
-		return
-	}
+	survey := midwares.GetSurvey(c)
 	//获取问卷收集数据
 	var num *int64
-	answers, num, err := adminService.GetSurveyAnswers(data.ID, data.PageNum, data.PageSize)
-	if err != nil {
+	answers, num, err := adminService.GetSurveyAnswers(survey.ID, data.PageNum, data.PageSize, data.AnswerFilter)
+	if err == adminService.ErrInvalidFilter {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	} else if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
@@ -303,7 +329,8 @@ func GetAllSurvey(c *gin.Context) {
 	// 获取问卷
 	response := make([]interface{}, 0)
 	var totalPageNum *int64
-	if user.AdminType == 2 {
+	// 超级管理员和审计员可查看全部问卷
+	if user.AdminType == models.AdminTypeSuper || user.AdminType == models.AdminTypeAuditor {
 		response, totalPageNum = adminService.GetAllSurvey(data.PageNum, data.PageSize, data.Title)
 		if err != nil {
 			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
@@ -335,6 +362,7 @@ func GetAllSurvey(c *gin.Context) {
 				"title":  managedSurvey.Title,
 				"status": managedSurvey.Status,
 				"num":    managedSurvey.Num,
+				"role":   manage.Role, //协作者权限
 			}
 			response = append(response, managedSurveyResponse)
 		}
@@ -368,25 +396,7 @@ func GetSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	user, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
-		return
-	}
-	// 获取问卷
-	survey, err := adminService.GetSurveyByID(data.ID)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	//判断权限
-	if (user.AdminType != 2) && (user.AdminType != 1 || survey.UserID != user.ID) && !adminService.UserInManage(user.ID, survey.ID) {
-		c.Error(errors.New("无权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
-		return
-	}
+	survey := midwares.GetSurvey(c)
 	// 获取相应的问题
 	questions, err := userService.GetQuestionsBySurveyID(survey.ID)
 	if err != nil {
@@ -406,41 +416,72 @@ func GetSurvey(c *gin.Context) {
 		optionsResponse := make([]map[string]interface{}, 0)
 		for _, option := range options {
 			optionResponse := map[string]interface{}{
-				"img":		 option.Img,
-				"content":     option.Content,
-				"serial_num":  option.SerialNum,
+				"img":        option.Img,
+				"content":    option.Content,
+				"serial_num": option.SerialNum,
+				"skip_to":    option.SkipTo,
+				"quota":      option.Quota,
+				"used":       option.Used,
+				"is_correct": option.IsCorrect,
+				"pin_last":   option.PinLast,
 			}
 			optionsResponse = append(optionsResponse, optionResponse)
 		}
 		questionMap := map[string]interface{}{
-			"id":            question.SerialNum,
-			"serial_num":    question.SerialNum,
-			"subject":       question.Subject,
-			"description":   question.Description,
-			"required":      question.Required,
-			"unique":        question.Unique,
-			"other_option":  question.OtherOption,
-			"img":           question.Img,
-			"question_type": question.QuestionType,
-			"reg":           question.Reg,
-			"options":       optionsResponse,
+			"id":                 question.SerialNum,
+			"serial_num":         question.SerialNum,
+			"subject":            question.Subject,
+			"description":        question.Description,
+			"required":           question.Required,
+			"unique":             question.Unique,
+			"other_option":       question.OtherOption,
+			"img":                question.Img,
+			"question_type":      question.QuestionType,
+			"reg":                question.Reg,
+			"display_serial_num": question.DisplaySerialNum,
+			"display_option":     question.DisplayOption,
+			"stable_id":          adminService.GetQuestionKey(question),
+			"score":              question.Score,
+			"accepted_answers":   userService.SplitAcceptedAnswers(question.AcceptedAnswers),
+			"scale_min":          question.ScaleMin,
+			"scale_max":          question.ScaleMax,
+			"scale_step":         question.ScaleStep,
+			"min_label":          question.MinLabel,
+			"max_label":          question.MaxLabel,
+			"matrix_rows":        userService.SplitMatrixRows(question.MatrixRows),
+			"rank_limit":         question.RankLimit,
+			"block":              question.Block,
+			"shuffle_options":    question.ShuffleOptions,
+			"options":            optionsResponse,
 		}
 		questionsResponse = append(questionsResponse, questionMap)
 	}
 	response := map[string]interface{}{
-		"id":        survey.ID,
-		"title":     survey.Title,
-		"time":      survey.Deadline.Format("2006-01-02 15:04:05"),
-		"desc":      survey.Desc,
-		"img":       survey.Img,
-		"questions": questionsResponse,
+		"id":                survey.ID,
+		"title":             survey.Title,
+		"time":              survey.Deadline.Format("2006-01-02 15:04:05"),
+		"desc":              survey.Desc,
+		"img":               survey.Img,
+		"version":           survey.Version,
+		"status":            survey.Status,
+		"start_time":        formatStartTime(survey.StartTime),
+		"submit_limit":      survey.SubmitLimit,
+		"limit_window":      survey.LimitWindow,
+		"max_responses":     survey.MaxResponses,
+		"num":               survey.Num,
+		"quiz_mode":         survey.QuizMode,
+		"shuffle_questions": survey.ShuffleQuestions,
+		"permission":        midwares.GetPermission(c), //当前管理员对问卷的权限
+		"questions":         questionsResponse,
 	}
 
 	utils.JsonSuccessResponse(c, response)
 }
 
 type DownloadFileData struct {
-	ID int `form:"id" binding:"required"`
+	ID     int    `form:"id" binding:"required"`
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl xlsx"`
+	adminService.AnswerFilter
 }
 
 // 下载
@@ -452,135 +493,62 @@ func DownloadFile(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	user, err := sessionService.GetUserSession(c)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.NotLogin)
-		return
-	}
-	// 获取问卷
-	survey, err := adminService.GetSurveyByID(data.ID)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	// 判断权限
-	if (user.AdminType != 2) && (user.AdminType != 1 || survey.UserID != user.ID) && !adminService.UserInManage(user.ID, survey.ID) {
-		c.Error(errors.New("无权限"))
-		utils.JsonErrorResponse(c, apiException.NoPermission)
-		return
-	}
-	// 获取数据
-	answers, err := adminService.GetAllSurveyAnswers(data.ID)
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
+	if data.Format == "" {
+		data.Format = adminService.ExportXLSX
 	}
-	questionAnswers := answers.QuestionAnswers
-	times := answers.Time
-	// 创建一个新的Excel文件
-	f := excelize.NewFile()
-	streamWriter, err := f.NewStreamWriter("Sheet1")
-	if err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
+	survey := midwares.GetSurvey(c)
+	w := &downloadWriter{
+		c:           c,
+		fileName:    survey.Title + "." + data.Format,
+		contentType: adminService.ExportContentTypes[data.Format],
 	}
-	// 设置字体样式
-	styleID, err := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true},
-	})
+	err = adminService.ExportSurveyAnswers(survey, data.Format, data.AnswerFilter, w)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	// 计算每列的最大宽度
-	maxWidths := make(map[int]int)
-	maxWidths[0] = 7
-	maxWidths[1] = 20
-	for i, qa := range questionAnswers {
-		maxWidths[i+2] = len(qa.Title)
-		for _, answer := range qa.Answers {
-			if len(answer) > maxWidths[i+2] {
-				maxWidths[i+2] = len(answer)
-			}
-		}
-	}
-	// 设置列宽
-	for colIndex, width := range maxWidths {
-		if err := streamWriter.SetColWidth(colIndex+1, colIndex+1, float64(width)); err != nil {
-			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-			utils.JsonErrorResponse(c, apiException.ServerError)
+		// 开始写入后无法再返回错误信息
+		if w.started {
+			c.Abort()
 			return
 		}
-	}
-	// 写入标题行
-	rowData := make([]interface{}, 0)
-	rowData = append(rowData, excelize.Cell{Value: "序号", StyleID: styleID}, excelize.Cell{Value: "提交时间", StyleID: styleID})
-	for _, qa := range questionAnswers {
-		rowData = append(rowData, excelize.Cell{Value: qa.Title, StyleID: styleID})
-	}
-	if err := streamWriter.SetRow("A1", rowData); err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
-		return
-	}
-	// 写入数据
-	for i, time := range times {
-		row := []interface{}{i + 1, time}
-		for j, qa := range questionAnswers {
-			if len(qa.Answers) <= i {
-				continue
-			}
-			answer := qa.Answers[i]
-			row = append(row, answer)
-			colName, _ := excelize.ColumnNumberToName(j + 3)
-			if err := f.SetCellValue("Sheet1", colName+strconv.Itoa(i+2), answer); err != nil {
-				c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-				utils.JsonErrorResponse(c, apiException.ServerError)
-				return
-			}
-		}
-		if err := streamWriter.SetRow(fmt.Sprintf("A%d", i+2), row); err != nil {
-			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-			utils.JsonErrorResponse(c, apiException.ServerError)
+		if err == adminService.ErrInvalidFilter {
+			utils.JsonErrorResponse(c, apiException.ParamError)
 			return
 		}
-	}
-	// 关闭
-	if err := streamWriter.Flush(); err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	// 保存Excel文件
-	fileName := survey.Title + ".xlsx"
-	filePath := "./xlsx/" + fileName
-	if _, err := os.Stat("./xlsx"); os.IsNotExist(err) {
-		err := os.Mkdir("./xlsx", 0755)
-		if err != nil {
-			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-			utils.JsonErrorResponse(c, apiException.ServerError)
-			return
-		}
-	}
-	// 删除旧文件
-	if _, err := os.Stat(filePath); err == nil {
-		if err := os.Remove(filePath); err != nil {
-			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-			utils.JsonErrorResponse(c, apiException.ServerError)
-			return
-		}
-	}
-	// 保存
-	if err := f.SaveAs(filePath); err != nil {
-		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-		utils.JsonErrorResponse(c, apiException.ServerError)
+	// 没有写入内容时也返回空文件
+	w.start()
+}
+
+// 第一次写入时才设置下载的响应头，写入前出错仍可返回JSON错误
+type downloadWriter struct {
+	c           *gin.Context
+	fileName    string
+	contentType string
+	started     bool
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.c.Writer.Write(p)
+}
+
+func (w *downloadWriter) start() {
+	if w.started {
 		return
 	}
+	w.started = true
+	w.c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(w.fileName))
+	w.c.Header("Content-Type", w.contentType)
+	w.c.Status(http.StatusOK)
+	w.c.Writer.WriteHeaderNow()
+}
 
-	utils.JsonSuccessResponse(c, config.Config.GetString("url.host")+"/xlsx/"+fileName)
+// 未设置开始时间时返回空字符串
+func formatStartTime(startTime *time.Time) string {
+	if startTime == nil {
+		return ""
+	}
+	return startTime.Format("2006-01-02 15:04:05")
 }
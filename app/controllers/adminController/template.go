@@ -0,0 +1,107 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 复制问卷
+type CloneSurveyData struct {
+	ID int `json:"id" binding:"required"`
+}
+
+func CloneSurvey(c *gin.Context) {
+	var data CloneSurveyData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	user := midwares.GetUser(c)
+	survey := midwares.GetSurvey(c)
+	//复制问卷
+	id, err := adminService.CloneSurvey(user.ID, survey.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{"id": id})
+}
+
+// 设置问卷是否为模板
+type UpdateSurveyTemplateData struct {
+	ID         int  `json:"id" binding:"required"`
+	IsTemplate bool `json:"is_template"`
+}
+
+func UpdateSurveyTemplate(c *gin.Context) {
+	var data UpdateSurveyTemplateData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	survey := midwares.GetSurvey(c)
+	err = adminService.UpdateSurveyTemplate(survey.ID, data.IsTemplate)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, nil)
+}
+
+// 获取模板列表
+func GetTemplates(c *gin.Context) {
+	templates, err := adminService.GetTemplates()
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{"template_list": templates})
+}
+
+// 使用模板新建问卷
+type UseTemplateData struct {
+	ID int `json:"id" binding:"required"`
+}
+
+func UseTemplate(c *gin.Context) {
+	var data UseTemplateData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	user := midwares.GetUser(c)
+	// 获取模板
+	survey, err := adminService.GetSurveyByID(data.ID)
+	if err == gorm.ErrRecordNotFound || (err == nil && !survey.IsTemplate) {
+		c.Error(errors.New("模板不存在"))
+		utils.JsonErrorResponse(c, apiException.TemplateNotExist)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	//复制模板
+	id, err := adminService.CloneSurvey(user.ID, survey.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{"id": id})
+}
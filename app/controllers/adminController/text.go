@@ -0,0 +1,42 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
+	"QA-System/app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取填空题和简答题的高频词元统计
+type GetTextStatisticsData struct {
+	ID    int `form:"id" binding:"required"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+	adminService.AnswerFilter
+}
+
+func GetTextStatistics(c *gin.Context) {
+	var data GetTextStatisticsData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	if data.Limit == 0 {
+		data.Limit = 20
+	}
+	survey := midwares.GetSurvey(c)
+	statistics, err := adminService.GetTextStatistics(survey, data.Limit, data.AnswerFilter)
+	if err == adminService.ErrInvalidFilter {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{"questions": statistics})
+}
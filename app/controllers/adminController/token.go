@@ -0,0 +1,98 @@
+package adminController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/services/adminService"
+	"QA-System/app/services/sessionService"
+	"QA-System/app/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 创建API令牌
+type CreateTokenData struct {
+	Name      string   `json:"name" binding:"required,max=50"`
+	Scopes    []string `json:"scopes" binding:"required,min=1,dive,oneof=read write export"`
+	ExpiresIn int      `json:"expires_in" binding:"required,min=1,max=365"` //有效天数
+}
+
+func CreateToken(c *gin.Context) {
+	var data CreateTokenData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	user, err := sessionService.GetUserSession(c)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.NotLogin)
+		return
+	}
+	expiresAt := time.Now().AddDate(0, 0, data.ExpiresIn)
+	raw, token, err := adminService.CreateToken(user.ID, data.Name, data.Scopes, expiresAt)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	// 令牌明文只在创建时返回一次
+	utils.JsonSuccessResponse(c, gin.H{
+		"id":         token.ID,
+		"token":      raw,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt.Format("2006-01-02 15:04:05"),
+	})
+}
+
+// 获取自己的API令牌
+func GetTokens(c *gin.Context) {
+	user, err := sessionService.GetUserSession(c)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.NotLogin)
+		return
+	}
+	tokens, err := adminService.GetTokensByUserID(user.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, tokens)
+}
+
+// 撤销API令牌
+type DeleteTokenData struct {
+	ID int `form:"id" binding:"required"`
+}
+
+func DeleteToken(c *gin.Context) {
+	var data DeleteTokenData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	user, err := sessionService.GetUserSession(c)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.NotLogin)
+		return
+	}
+	err = adminService.DeleteToken(user.ID, data.ID)
+	if err == gorm.ErrRecordNotFound {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.TokenNotExist)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, nil)
+}
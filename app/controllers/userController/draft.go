@@ -0,0 +1,114 @@
+package userController
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/services/userService"
+	"QA-System/app/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// 保存草稿
+type SaveDraftData struct {
+	ID            int                         `json:"id" binding:"required"`
+	Token         string                      `json:"token"`
+	QuestionsList []userService.QuestionsList `json:"questions_list"`
+}
+
+func SaveDraft(c *gin.Context) {
+	var data SaveDraftData
+	err := c.ShouldBindJSON(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	// 获取问卷
+	survey, err := userService.GetSurveyByID(data.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.SurveyNotExist)
+		return
+	}
+	// 判断问卷是否处于可填写状态
+	if apiErr := userService.CheckSurveyOpen(survey); apiErr != nil {
+		c.Error(errors.New(apiErr.Msg))
+		utils.JsonErrorResponse(c, apiErr)
+		return
+	}
+	// 判断问题是否属于该问卷
+	questions, err := userService.GetQuestionsBySurveyID(survey.ID)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	questionIDs := make(map[int]bool)
+	for _, question := range questions {
+		questionIDs[question.ID] = true
+	}
+	for _, q := range data.QuestionsList {
+		if !questionIDs[q.QuestionID] {
+			c.Error(errors.New("问题不属于该问卷"))
+			utils.JsonErrorResponse(c, apiException.QuestionNotMatch)
+			return
+		}
+	}
+	// 已有草稿时覆盖，否则生成新的续填标识
+	if data.Token != "" {
+		draft, err := userService.GetDraft(data.Token)
+		if err != nil && err != redis.Nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.ServerError)
+			return
+		}
+		if err == redis.Nil || draft.SurveyID != survey.ID {
+			data.Token = ""
+		}
+	}
+	if data.Token == "" {
+		data.Token = uuid.New().String()
+	}
+	expireAt, err := userService.SaveDraft(data.Token, userService.Draft{
+		SurveyID:      survey.ID,
+		QuestionsList: data.QuestionsList,
+	})
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, gin.H{
+		"token":     data.Token,
+		"expire_at": expireAt.Format("2006-01-02 15:04:05"),
+	})
+}
+
+// 获取草稿
+type GetDraftData struct {
+	Token string `form:"token" binding:"required"`
+}
+
+func GetDraft(c *gin.Context) {
+	var data GetDraftData
+	err := c.ShouldBindQuery(&data)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ParamError)
+		return
+	}
+	draft, err := userService.GetDraft(data.Token)
+	if err == redis.Nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.DraftNotExist)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	utils.JsonSuccessResponse(c, draft)
+}
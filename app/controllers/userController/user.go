@@ -2,7 +2,9 @@ package userController
 
 import (
 	"QA-System/app/apiException"
+	"QA-System/app/models"
 	"QA-System/app/services/adminService"
+	"QA-System/app/services/mongodbService"
 	"QA-System/app/services/userService"
 	"QA-System/app/utils"
 	"QA-System/config/config"
@@ -14,18 +16,19 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 
 	"github.com/disintegration/imaging"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/nfnt/resize"
 )
 
 type SubmitServeyData struct {
 	ID            int                         `json:"id" binding:"required"`
+	Token         string                      `json:"token"` //草稿续填标识
 	QuestionsList []userService.QuestionsList `json:"questions_list"`
 }
 
@@ -37,7 +40,7 @@ func SubmitSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ParamError)
 		return
 	}
-	// 判断问卷问题和答卷问题数目是否一致
+	// 获取问卷
 	survey, err := userService.GetSurveyByID(data.ID)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
@@ -50,44 +53,47 @@ func SubmitSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	if len(questions) != len(data.QuestionsList) {
-		c.Error(errors.New("问题数目不一致"))
-		utils.JsonErrorResponse(c, apiException.ServerError)
+	// 判断问卷是否处于可填写状态
+	if apiErr := userService.CheckSurveyOpen(survey); apiErr != nil {
+		c.Error(errors.New(apiErr.Msg))
+		utils.JsonErrorResponse(c, apiErr)
 		return
 	}
-	// 判断填写时间是否在问卷有效期内
-	if !survey.Deadline.IsZero() && survey.Deadline.Before(time.Now()) {
-		c.Error(errors.New("填写时间已过"))
-		utils.JsonErrorResponse(c, apiException.TimeBeyondError)
-		return
-	}
-	// 逐个判断问题答案
-	for _, q := range data.QuestionsList {
-		question, err := userService.GetQuestionByID(q.QuestionID)
-		if err != nil {
-			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
-			utils.JsonErrorResponse(c, apiException.ServerError)
+	// 合并草稿中已保存的答案
+	if data.Token != "" {
+		draft, err := userService.GetDraft(data.Token)
+		if err == redis.Nil || (err == nil && draft.SurveyID != survey.ID) {
+			c.Error(errors.New("草稿不存在"))
+			utils.JsonErrorResponse(c, apiException.DraftNotExist)
 			return
-		}
-		if question.SerialNum != q.SerialNum {
-			c.Error(errors.New("问题序号不一致"))
-			utils.JsonErrorResponse(c, apiException.ServerError)
-			return
-		}
-		if question.SurveyID != survey.ID {
-			c.Error(errors.New("问题不属于该问卷"))
+		} else if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 			utils.JsonErrorResponse(c, apiException.ServerError)
 			return
 		}
-		// 判断必填字段是否为空
-		if question.Required && q.Answer == "" {
-			c.Error(errors.New("必填字段为空"))
+		data.QuestionsList = userService.MergeDraft(draft.QuestionsList, data.QuestionsList)
+	}
+	// 按题型和跳转逻辑校验答案
+	err = userService.CheckAnswers(questions, data.QuestionsList)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		var answerErr *userService.AnswerError
+		if errors.As(err, &answerErr) {
+			utils.JsonErrorResponseWithData(c, answerErr.Err, answerErr)
+		} else {
 			utils.JsonErrorResponse(c, apiException.ServerError)
-			return
 		}
-		// 判断唯一字段是否唯一
-		if question.Unique {
-			unique, err := userService.CheckUnique(data.ID, q.QuestionID, question.SerialNum, q.Answer)
+		return
+	}
+	// 判断唯一字段是否唯一
+	questionMap := make(map[int]models.Question)
+	for _, question := range questions {
+		questionMap[question.ID] = question
+	}
+	for _, q := range data.QuestionsList {
+		question := questionMap[q.QuestionID]
+		if question.Unique && q.Answer != "" {
+			unique, err := userService.CheckUnique(data.ID, question, q.Answer)
 			if err != nil {
 				c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
 				utils.JsonErrorResponse(c, apiException.ServerError)
@@ -95,22 +101,117 @@ func SubmitSurvey(c *gin.Context) {
 			}
 			if !unique {
 				c.Error(errors.New("唯一字段不唯一"))
-				utils.JsonErrorResponse(c, apiException.UniqueError)
+				utils.JsonErrorResponseWithData(c, apiException.UniqueError, &userService.AnswerError{
+					QuestionID: question.ID,
+					SerialNum:  question.SerialNum,
+					Reason:     "填写内容已被提交过",
+				})
 				return
 			}
-
 		}
 	}
+	// 测验模式下评分
+	score, fullScore := 0, 0
+	if survey.QuizMode {
+		score, fullScore, err = userService.GradeAnswers(questions, data.QuestionsList)
+		if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.ServerError)
+			return
+		}
+	}
+	// 记录填写者看到的题目和选项顺序，与获取问卷时使用相同的种子
+	var order *mongodbService.PresentedOrder
+	if seed, err := c.Cookie(respondentCookie); err == nil && seed != "" {
+		order, err = userService.GetPresentedOrder(survey, questions, seed)
+		if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.ServerError)
+			return
+		}
+	}
+	// 判断提交次数限制
+	respondent, err := getRespondent(c, survey)
+	if err == userService.ErrRespondentIdentity {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.IdentityRequired)
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.NotLogin)
+		return
+	}
+	ok, err := userService.AcquireSubmitLimit(survey, respondent)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		return
+	}
+	if !ok {
+		c.Error(errors.New("重复提交"))
+		utils.JsonErrorResponse(c, apiException.SubmitLimitError)
+		return
+	}
+	// 占用问卷和选项名额
+	err = userService.AcquireQuota(survey, data.QuestionsList)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		_ = userService.ReleaseSubmitLimit(survey, respondent)
+		var answerErr *userService.AnswerError
+		if err == userService.ErrSurveyFull {
+			utils.JsonErrorResponse(c, apiException.SurveyFullError)
+		} else if errors.As(err, &answerErr) {
+			utils.JsonErrorResponseWithData(c, answerErr.Err, answerErr)
+		} else {
+			utils.JsonErrorResponse(c, apiException.ServerError)
+		}
+		return
+	}
 	// 提交问卷
-	err = userService.SubmitSurvey(data.ID, data.QuestionsList)
+	err = userService.SubmitSurvey(data.ID, survey.Version, score, order, data.QuestionsList)
 	if err != nil {
 		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		_ = userService.ReleaseSubmitLimit(survey, respondent)
+		_ = userService.ReleaseQuota(survey, data.QuestionsList)
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
+	// 提交成功后删除草稿
+	if data.Token != "" {
+		if err := userService.DeleteDraft(data.Token); err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		}
+	}
+	if survey.QuizMode {
+		utils.JsonSuccessResponse(c, gin.H{"score": score, "full_score": fullScore})
+		return
+	}
 	utils.JsonSuccessResponse(c, nil)
 }
 
+const respondentCookie = "qa-respondent"
+
+// 按身份限制提交时携带填写者身份令牌的请求头
+const respondentTokenHeader = "X-Respondent-Token"
+
+// 按问卷的提交限制获取填写者标识
+func getRespondent(c *gin.Context, survey models.Survey) (string, error) {
+	switch survey.SubmitLimit {
+	case models.SubmitLimitToken:
+		token, err := c.Cookie(respondentCookie)
+		if err != nil || token == "" {
+			return "", errors.New("缺少填写者标识")
+		}
+		return token, nil
+	case models.SubmitLimitIP:
+		return c.ClientIP(), nil
+	case models.SubmitLimitIdentity:
+		// 填写者身份由接入方签发，不使用管理员会话
+		return userService.VerifyRespondentToken(c.GetHeader(respondentTokenHeader))
+	}
+	return "", nil
+}
+
 type GetSurveyData struct {
 	ID int `form:"id" binding:"required"`
 }
@@ -139,12 +240,18 @@ func GetSurvey(c *gin.Context) {
 		utils.JsonErrorResponse(c, apiException.ServerError)
 		return
 	}
-	// 判断填写时间是否在问卷有效期内
-	if !survey.Deadline.IsZero() && survey.Deadline.Before(time.Now()) {
-		c.Error(errors.New("填写时间已过"))
-		utils.JsonErrorResponse(c, apiException.TimeBeyondError)
+	// 判断问卷是否处于可填写状态
+	if apiErr := userService.CheckSurveyOpen(survey); apiErr != nil {
+		c.Error(errors.New(apiErr.Msg))
+		utils.JsonErrorResponse(c, apiErr)
 		return
 	}
+	// 为填写者分配浏览器标识，同时作为打乱顺序的种子
+	seed, err := c.Cookie(respondentCookie)
+	if err != nil || seed == "" {
+		seed = uuid.New().String()
+		c.SetCookie(respondentCookie, seed, 3600*24*365, "/", "", false, true)
+	}
 	// 获取相应的问题
 	questions, err := userService.GetQuestionsBySurveyID(survey.ID)
 	if err != nil {
@@ -154,7 +261,7 @@ func GetSurvey(c *gin.Context) {
 	}
 	// 构建问卷响应
 	questionsResponse := make([]map[string]interface{}, 0)
-	for _, question := range questions {
+	for _, question := range userService.ShuffleQuestions(survey, questions, seed) {
 		options, err := userService.GetOptionsByQuestionID(question.ID)
 		if err != nil {
 			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
@@ -162,36 +269,51 @@ func GetSurvey(c *gin.Context) {
 			return
 		}
 		optionsResponse := make([]map[string]interface{}, 0)
-		for _, option := range options {
+		for _, option := range userService.ShuffleOptions(question, options, seed) {
 			optionResponse := map[string]interface{}{
-				"img":         option.Img,
-				"content":     option.Content,
-				"serial_num":  option.SerialNum,
+				"img":        option.Img,
+				"content":    option.Content,
+				"serial_num": option.SerialNum,
+				"skip_to":    option.SkipTo,
+				"quota":      option.Quota,
+				"remaining":  userService.GetRemaining(option.Quota, option.Used),
 			}
 			optionsResponse = append(optionsResponse, optionResponse)
 		}
 		questionMap := map[string]interface{}{
-			"id":            question.ID,
-			"serial_num":    question.SerialNum,
-			"subject":       question.Subject,
-			"describe":      question.Description,
-			"required":      question.Required,
-			"unique":        question.Unique,
-			"other_option":  question.OtherOption,
-			"img":           question.Img,
-			"question_type": question.QuestionType,
-			"reg":           question.Reg,
-			"options":       optionsResponse,
+			"id":                 question.ID,
+			"serial_num":         question.SerialNum,
+			"subject":            question.Subject,
+			"describe":           question.Description,
+			"required":           question.Required,
+			"unique":             question.Unique,
+			"other_option":       question.OtherOption,
+			"img":                question.Img,
+			"question_type":      question.QuestionType,
+			"reg":                question.Reg,
+			"display_serial_num": question.DisplaySerialNum,
+			"display_option":     question.DisplayOption,
+			"stable_id":          adminService.GetQuestionKey(question),
+			"scale_min":          question.ScaleMin,
+			"scale_max":          question.ScaleMax,
+			"scale_step":         question.ScaleStep,
+			"min_label":          question.MinLabel,
+			"max_label":          question.MaxLabel,
+			"matrix_rows":        userService.SplitMatrixRows(question.MatrixRows),
+			"rank_limit":         question.RankLimit,
+			"options":            optionsResponse,
 		}
 		questionsResponse = append(questionsResponse, questionMap)
 	}
 	response := map[string]interface{}{
-		"id":        survey.ID,
-		"title":     survey.Title,
-		"time":      survey.Deadline.Format("2006-01-02 15:04:05"),
-		"desc":      survey.Desc,
-		"img":       survey.Img,
-		"questions": questionsResponse,
+		"id":            survey.ID,
+		"title":         survey.Title,
+		"time":          survey.Deadline.Format("2006-01-02 15:04:05"),
+		"desc":          survey.Desc,
+		"img":           survey.Img,
+		"max_responses": survey.MaxResponses,
+		"remaining":     userService.GetRemaining(survey.MaxResponses, survey.Num),
+		"questions":     questionsResponse,
 	}
 
 	utils.JsonSuccessResponse(c, response)
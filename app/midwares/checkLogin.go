@@ -2,14 +2,25 @@ package midwares
 
 import (
 	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"QA-System/app/services/adminService"
 	"QA-System/app/services/sessionService"
 	"QA-System/app/utils"
 	"errors"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+const tokenKey = "token"
+
 func CheckLogin(c *gin.Context) {
+	// 脚本调用时通过Authorization: Bearer携带API令牌
+	if raw, ok := getBearerToken(c); ok {
+		checkToken(c, raw)
+		return
+	}
 	isLogin := sessionService.CheckUserSession(c)
 	if !isLogin {
 		c.Error(errors.New("未登录"))
@@ -19,3 +30,65 @@ func CheckLogin(c *gin.Context) {
 	}
 	c.Next()
 }
+
+// 校验API令牌，GET请求需要read范围，其余请求需要write范围
+func checkToken(c *gin.Context, raw string) {
+	user, token, err := adminService.GetTokenUser(raw)
+	if err == adminService.ErrTokenInvalid {
+		c.Error(err)
+		utils.JsonErrorResponseWithStatus(c, apiException.TokenInvalid)
+		c.Abort()
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		c.Abort()
+		return
+	}
+	scope := models.ScopeWrite
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		scope = models.ScopeRead
+	}
+	if !adminService.HasScope(token, scope) {
+		c.Error(errors.New("令牌缺少授权范围" + scope))
+		utils.JsonErrorResponseWithStatus(c, apiException.TokenScopeError)
+		c.Abort()
+		return
+	}
+	sessionService.SetTokenUser(c, user)
+	c.Set(tokenKey, token)
+	c.Next()
+}
+
+// 通过API令牌访问时要求令牌包含指定范围，使用会话登录时直接放行
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := c.Get(tokenKey)
+		if ok && !adminService.HasScope(token.(models.Token), scope) {
+			c.Error(errors.New("令牌缺少授权范围" + scope))
+			utils.JsonErrorResponseWithStatus(c, apiException.TokenScopeError)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 只允许会话登录访问，用于令牌管理等不能由令牌自身完成的操作
+func RequireSession(c *gin.Context) {
+	if _, ok := c.Get(tokenKey); ok {
+		c.Error(errors.New("该接口不支持令牌访问"))
+		utils.JsonErrorResponseWithStatus(c, apiException.TokenScopeError)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func getBearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
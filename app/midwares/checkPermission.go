@@ -0,0 +1,192 @@
+package midwares
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"QA-System/app/services/adminService"
+	"QA-System/app/services/mongodbService"
+	"QA-System/app/services/sessionService"
+	"QA-System/app/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+const (
+	userKey        = "user"
+	surveyKey      = "survey"
+	permissionKey  = "permission"
+	answerSheetKey = "answer_sheet"
+)
+
+// 加载请求中的问卷并校验当前管理员对问卷的权限，问卷ID取自参数id或survey_id，权限不足时返回403
+func CheckSurveyPermission(permission int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getSurveyID(c)
+		if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+			utils.JsonErrorResponse(c, apiException.ParamError)
+			c.Abort()
+			return
+		}
+		authorizeSurvey(c, id, permission)
+	}
+}
+
+// 加载参数id对应的答卷，并按答卷所属问卷校验权限
+func CheckResponsePermission(permission int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		answerSheet, err := adminService.GetAnswerSheetByID(c.Query("id"))
+		if err == primitive.ErrInvalidHex {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypeBind})
+			utils.JsonErrorResponse(c, apiException.ParamError)
+			c.Abort()
+			return
+		} else if err == mongo.ErrNoDocuments {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.ResponseNotExist)
+			c.Abort()
+			return
+		} else if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.ServerError)
+			c.Abort()
+			return
+		}
+		c.Set(answerSheetKey, answerSheet)
+		authorizeSurvey(c, answerSheet.SurveyID, permission)
+	}
+}
+
+func authorizeSurvey(c *gin.Context, id int, permission int) {
+	user, err := sessionService.GetUserSession(c)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.NotLogin)
+		c.Abort()
+		return
+	}
+	survey, err := adminService.GetSurveyByID(id)
+	if err == gorm.ErrRecordNotFound {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.SurveyNotExist)
+		c.Abort()
+		return
+	} else if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		c.Abort()
+		return
+	}
+	effective, err := adminService.GetSurveyPermission(user, survey)
+	if err != nil {
+		c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+		utils.JsonErrorResponse(c, apiException.ServerError)
+		c.Abort()
+		return
+	}
+	if effective < permission {
+		c.Error(errors.New("无权限"))
+		utils.JsonErrorResponseWithStatus(c, apiException.NoPermission)
+		c.Abort()
+		return
+	}
+	c.Set(userKey, user)
+	c.Set(surveyKey, survey)
+	c.Set(permissionKey, effective)
+	c.Next()
+}
+
+// 只允许指定类型的管理员访问，权限不足时返回403
+func CheckAdminType(adminTypes ...int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := sessionService.GetUserSession(c)
+		if err != nil {
+			c.Error(&gin.Error{Err: err, Type: gin.ErrorTypePublic})
+			utils.JsonErrorResponse(c, apiException.NotLogin)
+			c.Abort()
+			return
+		}
+		for _, adminType := range adminTypes {
+			if user.AdminType == adminType {
+				c.Set(userKey, user)
+				c.Next()
+				return
+			}
+		}
+		c.Error(errors.New("无权限"))
+		utils.JsonErrorResponseWithStatus(c, apiException.NoPermission)
+		c.Abort()
+	}
+}
+
+// 获取中间件校验过的管理员
+func GetUser(c *gin.Context) *models.User {
+	return c.MustGet(userKey).(*models.User)
+}
+
+// 获取中间件加载的问卷
+func GetSurvey(c *gin.Context) models.Survey {
+	return c.MustGet(surveyKey).(models.Survey)
+}
+
+// 获取当前管理员对问卷的权限
+func GetPermission(c *gin.Context) int {
+	return c.MustGet(permissionKey).(int)
+}
+
+// 获取中间件加载的答卷
+func GetAnswerSheet(c *gin.Context) mongodbService.AnswerSheet {
+	return c.MustGet(answerSheetKey).(mongodbService.AnswerSheet)
+}
+
+// 从查询参数或JSON请求体中读取问卷ID，读取后还原请求体供处理函数绑定，多处给出的问卷ID必须一致
+func getSurveyID(c *gin.Context) (int, error) {
+	ids := make([]int, 0)
+	for _, key := range []string{"id", "survey_id"} {
+		if value := c.Query(key); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	if c.Request.Body != nil && c.ContentType() == gin.MIMEJSON {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return 0, err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			var data struct {
+				ID       int `json:"id"`
+				SurveyID int `json:"survey_id"`
+			}
+			if err := json.Unmarshal(body, &data); err != nil {
+				return 0, err
+			}
+			for _, id := range []int{data.ID, data.SurveyID} {
+				if id != 0 {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return 0, errors.New("缺少问卷ID")
+	}
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			return 0, errors.New("问卷ID不一致")
+		}
+	}
+	return ids[0], nil
+}
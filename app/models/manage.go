@@ -4,4 +4,15 @@ type Manage struct {
 	ID       int `json:"id"`
 	UserID   int `json:"user_id"`
 	SurveyID int `json:"survey_id"`
+	Role     int `json:"role" gorm:"default:3"` //协作者权限 1:查看结果 2:导出数据 3:编辑问卷 4:管理协作者
 }
+
+// 对问卷的权限，数值越大权限越高，高权限包含低权限
+const (
+	PermissionNone   = 0
+	PermissionView   = 1 //查看问卷、答卷和统计
+	PermissionExport = 2 //下载答卷和交叉分析
+	PermissionEdit   = 3 //修改问卷和答卷
+	PermissionManage = 4 //添加和移除协作者
+	PermissionOwner  = 5 //删除问卷、设置模板
+)
@@ -6,4 +6,9 @@ type Option struct {
 	SerialNum  int    `json:"serial_num"`  //选项序号
 	Content    string `json:"content"`     //选项内容
 	Img 	  string `json:"img"`         //选项图片
+	SkipTo     int    `json:"skip_to"`     //选择后跳转到的题目序号 0不跳转 -1结束问卷
+	Quota      int    `json:"quota"`       //选项名额，0表示不限
+	Used       int    `json:"used"`        //已占用的名额
+	IsCorrect  bool   `json:"is_correct"`  //测验模式下是否为正确选项
+	PinLast    bool   `json:"pin_last"`    //打乱选项顺序时固定在末尾，如"其他"
 }
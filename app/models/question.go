@@ -10,6 +10,21 @@ type Question struct {
 	Required     bool   `json:"required"`    //是否必填
 	Unique       bool   `json:"unique"`      //是否唯一
 	OtherOption  bool  `json:"other_option"` //是否有其他选项
-	QuestionType int    `json:"question_type"` //题目类型 1单选2多选3填空4简答5图片
+	QuestionType int    `json:"question_type"` //题目类型 1单选2多选3填空4简答5图片6评分7NPS8量表9矩阵单选10矩阵多选11排序
 	Reg          string `json:"reg"`           //正则表达式
+	DisplaySerialNum int    `json:"display_serial_num"` //显示条件依赖的题目序号 0表示始终显示
+	DisplayOption    string `json:"display_option"`     //依赖题目选中该选项时才显示
+	Version          int    `json:"version" gorm:"default:0"` //所属问卷版本
+	StableID         string `json:"stable_id"`                //跨版本不变的题目标识
+	Score            int    `json:"score"`                    //测验模式下的分值
+	AcceptedAnswers  string `json:"accepted_answers"`         //填空题和简答题的参考答案，多个答案以┋分隔
+	ScaleMin         int    `json:"scale_min"`                //量表题的最小值
+	ScaleMax         int    `json:"scale_max"`                //量表题的最大值
+	ScaleStep        int    `json:"scale_step"`               //量表题的步长
+	MinLabel         string `json:"min_label"`                //量表题最小值一端的标签
+	MaxLabel         string `json:"max_label"`                //量表题最大值一端的标签
+	MatrixRows       string `json:"matrix_rows"`              //矩阵题的行标题，以┋分隔，列为选项
+	Block            int    `json:"block"`                    //题目分组，打乱题目顺序时只在连续的同组题目内打乱
+	ShuffleOptions   bool   `json:"shuffle_options"`          //是否对每个填写者打乱选项顺序
+	RankLimit        int    `json:"rank_limit"`               //排序题只需排出前几名，0表示对全部选项排序
 }
@@ -8,7 +8,33 @@ type Survey struct {
 	Title    string    `json:"title"`    //问卷标题
 	Desc     string    `json:"desc"`     //问卷描述
 	Img      string    `json:"img"`      //问卷图片
+	StartTime *time.Time `json:"start_time"` //定时发布的开始时间，nil表示未设置
 	Deadline time.Time `json:"deadline"` //截止时间
-	Status   int       `json:"status"`   //问卷状态  1:未发布 2:已发布
+	Status   int       `json:"status"`   //问卷状态  1:未发布 2:已发布 3:待开始 4:已暂停 5:已结束 6:已归档
 	Num      int       `json:"num"`      //问卷填写数量
+	Version  int       `json:"version" gorm:"default:0"` //当前问卷版本，每次修改已有答卷的版本时递增
+	SubmitLimit int    `json:"submit_limit"` //提交限制 0:不限制 1:每个浏览器一次 2:每个IP在时间窗口内一次 3:每个登录账号一次
+	LimitWindow int    `json:"limit_window"` //IP限制的时间窗口，单位分钟，0表示不过期
+	IsTemplate  bool   `json:"is_template"`  //是否为模板，模板可被所有管理员使用
+	MaxResponses int   `json:"max_responses"` //最大答卷数，0表示不限，达到后自动结束
+	QuizMode     bool  `json:"quiz_mode"`     //是否为测验模式，提交时自动评分
+	ShuffleQuestions bool `json:"shuffle_questions"` //是否对每个填写者打乱题目顺序
 }
+
+// 问卷状态
+const (
+	SurveyDraft     = 1 //未发布
+	SurveyOpen      = 2 //已发布，正在收集
+	SurveyScheduled = 3 //待开始，到达开始时间后自动发布
+	SurveyPaused    = 4 //已暂停
+	SurveyClosed    = 5 //已结束
+	SurveyArchived  = 6 //已归档
+)
+
+// 问卷提交限制
+const (
+	SubmitLimitNone     = 0
+	SubmitLimitToken    = 1
+	SubmitLimitIP       = 2
+	SubmitLimitIdentity = 3
+)
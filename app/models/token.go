@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type Token struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`                               //令牌名称
+	Prefix     string     `json:"prefix"`                             //令牌前缀，用于辨认令牌
+	TokenHash  string     `json:"-" gorm:"type:char(64);uniqueIndex"` //令牌的SHA-256哈希，不保存明文
+	Scopes     string     `json:"scopes"`                             //授权范围，以逗号分隔
+	ExpiresAt  time.Time  `json:"expires_at"`                         //过期时间
+	LastUsedAt *time.Time `json:"last_used_at"`                       //最后使用时间
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// 令牌授权范围
+const (
+	ScopeRead   = "read"   //GET请求
+	ScopeWrite  = "write"  //修改类请求
+	ScopeExport = "export" //下载答卷等导出数据的请求
+)
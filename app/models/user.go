@@ -4,5 +4,12 @@ type User struct {
 	ID        int    `json:"id"`
 	Username  string `json:"username"`
 	Password  string `json:"password"`
-	AdminType int    `json:"admin_type"` //1:普通管理员	2:超级管理员
+	AdminType int    `json:"admin_type"` //1:普通管理员	2:超级管理员	3:审计员
 }
+
+// 管理员类型
+const (
+	AdminTypeNormal  = 1 //普通管理员，只能管理自己创建或被授权的问卷
+	AdminTypeSuper   = 2 //超级管理员，可管理所有问卷
+	AdminTypeAuditor = 3 //审计员，可查看所有问卷和日志，不能修改
+)
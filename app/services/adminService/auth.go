@@ -4,6 +4,11 @@ import (
 	"QA-System/app/models"
 	"QA-System/app/utils"
 	"QA-System/config/database"
+	"crypto/subtle"
+	"log"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func GetAdminByUsername(username string) (*models.User, error) {
@@ -12,9 +17,6 @@ func GetAdminByUsername(username string) (*models.User, error) {
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	if user.Password != "" {
-		aesDecryptPassword(&user)
-	}
 	return &user, result.Error
 }
 
@@ -24,7 +26,6 @@ func GetAdminByID(id int) (*models.User, error) {
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	aesDecryptPassword(&user)
 	return &user, nil
 }
 
@@ -35,15 +36,51 @@ func IsAdminExist(username string) error {
 }
 
 func CreateAdmin(user models.User) error {
-	aesEncryptPassword(&user)
+	hash, err := hashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
 	result := database.DB.Model(models.User{}).Create(&user)
 	return result.Error
 }
 
-func aesDecryptPassword(user *models.User) {
-	user.Password = utils.AesDecrypt(user.Password)
+// 校验密码，旧的AES加密密码校验通过后改存为哈希
+func CheckPassword(user *models.User, password string) (bool, error) {
+	if user.Password == "" {
+		return false, nil
+	}
+	if isPasswordHash(user.Password) {
+		err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.AesDecrypt(user.Password)), []byte(password)) != 1 {
+		return false, nil
+	}
+	// 密码已校验通过，改存哈希失败时保留原密码，下次登录再试
+	hash, err := hashPassword(password)
+	if err != nil {
+		log.Println("RehashPasswordFailed", user.ID, err)
+		return true, nil
+	}
+	err = database.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("password", hash).Error
+	if err != nil {
+		log.Println("RehashPasswordFailed", user.ID, err)
+		return true, nil
+	}
+	user.Password = hash
+	return true, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
 }
 
-func aesEncryptPassword(user *models.User) {
-	user.Password = utils.AesEncrypt(user.Password)
+// bcrypt哈希以$2a$、$2b$或$2y$开头，AES加密的密码为base64编码，不含$
+func isPasswordHash(password string) bool {
+	return strings.HasPrefix(password, "$2")
 }
@@ -0,0 +1,187 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/userService"
+	"QA-System/config/config"
+	"QA-System/config/database"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 复制问卷当前版本的题目和选项，生成归属于uid的未发布问卷，返回新问卷ID
+func CloneSurvey(uid int, id int) (int, error) {
+	survey, err := GetSurveyByID(id)
+	if err != nil {
+		return 0, err
+	}
+	questions, err := GetSurveyQuestions(survey)
+	if err != nil {
+		return 0, err
+	}
+	//复制失败时删除已复制的图片
+	var copied []string
+	newID, err := cloneSurvey(uid, survey, questions, &copied)
+	if err != nil {
+		for _, path := range copied {
+			_ = os.Remove(path)
+		}
+		return 0, err
+	}
+	return newID, nil
+}
+
+func cloneSurvey(uid int, survey models.Survey, questions []Question, copied *[]string) (int, error) {
+	//复制图片，避免删除原问卷时影响新问卷
+	img, err := duplicateImg(survey.Img, copied)
+	if err != nil {
+		return 0, err
+	}
+	for i := range questions {
+		questions[i].StableID = ""
+		questions[i].Img, err = duplicateImg(questions[i].Img, copied)
+		if err != nil {
+			return 0, err
+		}
+		for j := range questions[i].Options {
+			questions[i].Options[j].Img, err = duplicateImg(questions[i].Options[j].Img, copied)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	newSurvey := models.Survey{
+		UserID:           uid,
+		Title:            survey.Title,
+		Desc:             survey.Desc,
+		Img:              img,
+		Status:           models.SurveyDraft,
+		Deadline:         survey.Deadline,
+		SubmitLimit:      survey.SubmitLimit,
+		LimitWindow:      survey.LimitWindow,
+		MaxResponses:     survey.MaxResponses,
+		QuizMode:         survey.QuizMode,
+		ShuffleQuestions: survey.ShuffleQuestions,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&newSurvey).Error
+		if err != nil {
+			return err
+		}
+		_, err = createQuestionsAndOptions(tx, questions, newSurvey.ID, newSurvey.Version)
+		return err
+	})
+	return newSurvey.ID, err
+}
+
+// 获取问卷当前版本的题目及选项
+func GetSurveyQuestions(survey models.Survey) ([]Question, error) {
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]Question, 0)
+	for _, question := range questions {
+		var options []models.Option
+		err = database.DB.Where("question_id = ?", question.ID).Order("serial_num").Find(&options).Error
+		if err != nil {
+			return nil, err
+		}
+		q := Question{
+			ID:               question.ID,
+			SerialNum:        question.SerialNum,
+			Subject:          question.Subject,
+			Description:      question.Description,
+			Img:              question.Img,
+			Required:         question.Required,
+			Unique:           question.Unique,
+			OtherOption:      question.OtherOption,
+			QuestionType:     question.QuestionType,
+			Reg:              question.Reg,
+			DisplaySerialNum: question.DisplaySerialNum,
+			DisplayOption:    question.DisplayOption,
+			StableID:         GetQuestionKey(question),
+			Score:            question.Score,
+			AcceptedAnswers:  userService.SplitAcceptedAnswers(question.AcceptedAnswers),
+			ScaleMin:         question.ScaleMin,
+			ScaleMax:         question.ScaleMax,
+			ScaleStep:        question.ScaleStep,
+			MinLabel:         question.MinLabel,
+			MaxLabel:         question.MaxLabel,
+			MatrixRows:       userService.SplitMatrixRows(question.MatrixRows),
+			Block:            question.Block,
+			ShuffleOptions:   question.ShuffleOptions,
+			RankLimit:        question.RankLimit,
+			Options:          make([]Option, 0),
+		}
+		for _, option := range options {
+			q.Options = append(q.Options, Option{
+				SerialNum: option.SerialNum,
+				Content:   option.Content,
+				Img:       option.Img,
+				SkipTo:    option.SkipTo,
+				Quota:     option.Quota,
+				IsCorrect: option.IsCorrect,
+				PinLast:   option.PinLast,
+			})
+		}
+		response = append(response, q)
+	}
+	return response, nil
+}
+
+// 复制本地保存的图片并返回新图片地址，非本地图片原样返回，复制出的文件路径记录到copied
+func duplicateImg(img string, copied *[]string) (string, error) {
+	urlHost := config.Config.GetString("url.host")
+	prefix := urlHost + "/static/"
+	if img == "" || !strings.HasPrefix(img, prefix) {
+		return img, nil
+	}
+	// 只复制静态目录内的文件，防止通过../读取其他文件
+	path := filepath.Join("static", strings.TrimPrefix(img, prefix))
+	if rel, err := filepath.Rel("static", path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return img, nil
+	}
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return img, nil
+	} else if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	filename := uuid.New().String() + filepath.Ext(img)
+	dst, err := os.Create("./static/" + filename)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+	*copied = append(*copied, "./static/"+filename)
+	if _, err = io.Copy(dst, src); err != nil {
+		return "", err
+	}
+	return prefix + filename, nil
+}
+
+func UpdateSurveyTemplate(id int, isTemplate bool) error {
+	return database.DB.Model(&models.Survey{}).Where("id = ?", id).Update("is_template", isTemplate).Error
+}
+
+func GetTemplates() ([]interface{}, error) {
+	var surveys []models.Survey
+	err := database.DB.Where("is_template = ?", true).Order("id DESC").Find(&surveys).Error
+	response := make([]interface{}, 0)
+	for _, survey := range surveys {
+		response = append(response, map[string]interface{}{
+			"id":    survey.ID,
+			"title": survey.Title,
+			"desc":  survey.Desc,
+			"img":   survey.Img,
+		})
+	}
+	return response, err
+}
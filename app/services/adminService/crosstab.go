@@ -0,0 +1,379 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/config/database"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrCrosstabQuestion = errors.New("交叉分析的题目须为问卷当前版本的选择题")
+
+// 显著性水平
+const significanceLevel = 0.05
+
+// 未匹配任何选项的答案的统计键，不会与选项内容冲突
+const otherKey = "\x00other"
+
+type ChiSquareResult struct {
+	Statistic        float64 `json:"statistic"`          //卡方值
+	DegreesOfFreedom int     `json:"degrees_of_freedom"` //自由度
+	PValue           float64 `json:"p_value"`            //P值
+	Significant      bool    `json:"significant"`        //P值是否小于0.05
+}
+
+// 两道选择题的交叉表，多选题的每个选中选项分别计数，此时同一答卷会被计数多次，不进行卡方检验
+type Crosstab struct {
+	RowSubject        string           `json:"row_subject"`               //行题目
+	ColumnSubject     string           `json:"column_subject"`            //列题目
+	Rows              []string         `json:"rows"`                      //行选项
+	Columns           []string         `json:"columns"`                   //列选项
+	Counts            [][]int64        `json:"counts"`                    //频数
+	RowTotals         []int64          `json:"row_totals"`                //行合计
+	ColumnTotals      []int64          `json:"column_totals"`             //列合计
+	Total             int64            `json:"total"`                     //总计
+	RowPercentages    [][]float64      `json:"row_percentages"`           //行百分比
+	ColumnPercentages [][]float64      `json:"column_percentages"`        //列百分比
+	ChiSquare         *ChiSquareResult `json:"chi_square"`                //卡方检验，不适用时为null
+	ChiSquareNote     string           `json:"chi_square_note,omitempty"` //不进行卡方检验的原因
+}
+
+func GetCrosstab(survey models.Survey, rowID int, columnID int, filter AnswerFilter) (Crosstab, error) {
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return Crosstab{}, err
+	}
+	var rowQuestion, columnQuestion *models.Question
+	for i := range questions {
+		if questions[i].ID == rowID {
+			rowQuestion = &questions[i]
+		}
+		if questions[i].ID == columnID {
+			columnQuestion = &questions[i]
+		}
+	}
+	if rowQuestion == nil || columnQuestion == nil || rowID == columnID ||
+		!isChoiceQuestion(rowQuestion.QuestionType) || !isChoiceQuestion(columnQuestion.QuestionType) {
+		return Crosstab{}, ErrCrosstabQuestion
+	}
+	rowLabels, err := getOptionContents(*rowQuestion)
+	if err != nil {
+		return Crosstab{}, err
+	}
+	columnLabels, err := getOptionContents(*columnQuestion)
+	if err != nil {
+		return Crosstab{}, err
+	}
+	answerFilter, err := toMongoFilter(survey.ID, filter)
+	if err != nil {
+		return Crosstab{}, err
+	}
+	keys, err := getQuestionKeys(survey.ID)
+	if err != nil {
+		return Crosstab{}, err
+	}
+	rowKey, columnKey := GetQuestionKey(*rowQuestion), GetQuestionKey(*columnQuestion)
+	//统计选项组合的频数，未匹配任何选项的答案计入"其他"类别
+	pairs := make(map[[2]string]int64)
+	err = mongodbService.IterateAnswerSheetBySurveyID(survey.ID, answerFilter, func(answerSheet mongodbService.AnswerSheet) error {
+		var rowContent, columnContent string
+		for _, answer := range answerSheet.Answers {
+			switch keys[answer.QuestionID] {
+			case rowKey:
+				rowContent = answer.Content
+			case columnKey:
+				columnContent = answer.Content
+			}
+		}
+		if rowContent == "" || columnContent == "" {
+			return nil
+		}
+		for _, r := range strings.Split(rowContent, mongodbService.OptionSeparator) {
+			for _, c := range strings.Split(columnContent, mongodbService.OptionSeparator) {
+				pairs[[2]string{categorize(rowLabels, r), categorize(columnLabels, c)}]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Crosstab{}, err
+	}
+	rowLabels = appendOther(rowLabels, rowQuestion.OtherOption, pairs, 0)
+	columnLabels = appendOther(columnLabels, columnQuestion.OtherOption, pairs, 1)
+
+	crosstab := Crosstab{
+		RowSubject:    rowQuestion.Subject,
+		ColumnSubject: columnQuestion.Subject,
+		Rows:          otherLabels(rowLabels),
+		Columns:       otherLabels(columnLabels),
+		RowTotals:     make([]int64, len(rowLabels)),
+		ColumnTotals:  make([]int64, len(columnLabels)),
+	}
+	for i, r := range rowLabels {
+		counts := make([]int64, len(columnLabels))
+		for j, c := range columnLabels {
+			counts[j] = pairs[[2]string{r, c}]
+			crosstab.RowTotals[i] += counts[j]
+			crosstab.ColumnTotals[j] += counts[j]
+			crosstab.Total += counts[j]
+		}
+		crosstab.Counts = append(crosstab.Counts, counts)
+	}
+	for i := range rowLabels {
+		rowPercentages := make([]float64, len(columnLabels))
+		columnPercentages := make([]float64, len(columnLabels))
+		for j := range columnLabels {
+			rowPercentages[j] = percentage(crosstab.Counts[i][j], crosstab.RowTotals[i])
+			columnPercentages[j] = percentage(crosstab.Counts[i][j], crosstab.ColumnTotals[j])
+		}
+		crosstab.RowPercentages = append(crosstab.RowPercentages, rowPercentages)
+		crosstab.ColumnPercentages = append(crosstab.ColumnPercentages, columnPercentages)
+	}
+	//多选题的频数不是独立的观测，卡方检验不成立
+	if rowQuestion.QuestionType == 2 || columnQuestion.QuestionType == 2 {
+		crosstab.ChiSquareNote = "包含多选题，不进行卡方检验"
+	} else {
+		chiSquare := chiSquareTest(crosstab.Counts)
+		crosstab.ChiSquare = &chiSquare
+	}
+	return crosstab, nil
+}
+
+func getOptionContents(question models.Question) ([]string, error) {
+	var options []models.Option
+	err := database.DB.Where("question_id = ?", question.ID).Order("serial_num").Find(&options).Error
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]string, 0)
+	for _, option := range options {
+		contents = append(contents, option.Content)
+	}
+	return contents, nil
+}
+
+func categorize(labels []string, content string) string {
+	for _, label := range labels {
+		if label == content {
+			return content
+		}
+	}
+	return otherKey
+}
+
+// 题目有其他选项或出现未匹配的答案时追加"其他"类别
+func appendOther(labels []string, otherOption bool, pairs map[[2]string]int64, index int) []string {
+	if otherOption {
+		return append(labels, otherKey)
+	}
+	for pair := range pairs {
+		if pair[index] == otherKey {
+			return append(labels, otherKey)
+		}
+	}
+	return labels
+}
+
+// 将"其他"类别的统计键替换为显示名称，与选项内容重名时加以区分
+func otherLabels(labels []string) []string {
+	name := "其他"
+	if contains(labels, name) {
+		name = "其他(未匹配选项)"
+	}
+	result := make([]string, len(labels))
+	for i, label := range labels {
+		result[i] = label
+		if label == otherKey {
+			result[i] = name
+		}
+	}
+	return result
+}
+
+// Pearson卡方独立性检验，合计为0的行列不参与计算
+func chiSquareTest(counts [][]int64) ChiSquareResult {
+	rowTotals := make([]float64, len(counts))
+	var columnTotals []float64
+	var total float64
+	for i, row := range counts {
+		if columnTotals == nil {
+			columnTotals = make([]float64, len(row))
+		}
+		for j, count := range row {
+			rowTotals[i] += float64(count)
+			columnTotals[j] += float64(count)
+			total += float64(count)
+		}
+	}
+	rows, columns := 0, 0
+	for _, t := range rowTotals {
+		if t > 0 {
+			rows++
+		}
+	}
+	for _, t := range columnTotals {
+		if t > 0 {
+			columns++
+		}
+	}
+	result := ChiSquareResult{DegreesOfFreedom: (rows - 1) * (columns - 1), PValue: 1}
+	if result.DegreesOfFreedom <= 0 {
+		result.DegreesOfFreedom = 0
+		return result
+	}
+	for i, row := range counts {
+		for j, count := range row {
+			if rowTotals[i] == 0 || columnTotals[j] == 0 {
+				continue
+			}
+			expected := rowTotals[i] * columnTotals[j] / total
+			result.Statistic += (float64(count) - expected) * (float64(count) - expected) / expected
+		}
+	}
+	result.PValue = gammaQ(float64(result.DegreesOfFreedom)/2, result.Statistic/2)
+	result.Significant = result.PValue < significanceLevel
+	result.Statistic = math.Round(result.Statistic*10000) / 10000
+	result.PValue = math.Round(result.PValue*10000) / 10000
+	return result
+}
+
+// 正则化上不完全伽马函数Q(a, x)，即卡方分布的右尾概率
+func gammaQ(a float64, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lgamma, _ := math.Lgamma(a)
+	if x < a+1 {
+		// 级数展开
+		sum := 1 / a
+		term := sum
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lgamma)
+	}
+	// 连分式展开(Lentz算法)
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 1000; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lgamma) * h
+}
+
+// 以Excel格式写出交叉表
+func WriteCrosstabExcel(w io.Writer, crosstab Crosstab) error {
+	const sheet = "交叉分析"
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	row := 1
+	writeRow := func(values ...interface{}) error {
+		err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &values)
+		row++
+		return err
+	}
+	writeTable := func(title string, cell func(i, j int) interface{}, totals bool) error {
+		header := []interface{}{title}
+		for _, column := range crosstab.Columns {
+			header = append(header, column)
+		}
+		if totals {
+			header = append(header, "合计")
+		}
+		if err := writeRow(header...); err != nil {
+			return err
+		}
+		for i, r := range crosstab.Rows {
+			values := []interface{}{r}
+			for j := range crosstab.Columns {
+				values = append(values, cell(i, j))
+			}
+			if totals {
+				values = append(values, crosstab.RowTotals[i])
+			}
+			if err := writeRow(values...); err != nil {
+				return err
+			}
+		}
+		if totals {
+			values := []interface{}{"合计"}
+			for _, t := range crosstab.ColumnTotals {
+				values = append(values, t)
+			}
+			values = append(values, crosstab.Total)
+			if err := writeRow(values...); err != nil {
+				return err
+			}
+		}
+		row++
+		return nil
+	}
+	if err := writeRow("行：" + crosstab.RowSubject); err != nil {
+		return err
+	}
+	if err := writeRow("列：" + crosstab.ColumnSubject); err != nil {
+		return err
+	}
+	row++
+	if err := writeTable("频数", func(i, j int) interface{} { return crosstab.Counts[i][j] }, true); err != nil {
+		return err
+	}
+	if err := writeTable("行百分比(%)", func(i, j int) interface{} { return crosstab.RowPercentages[i][j] }, false); err != nil {
+		return err
+	}
+	if err := writeTable("列百分比(%)", func(i, j int) interface{} { return crosstab.ColumnPercentages[i][j] }, false); err != nil {
+		return err
+	}
+	if crosstab.ChiSquare == nil {
+		if err := writeRow("卡方检验", crosstab.ChiSquareNote); err != nil {
+			return err
+		}
+		return f.Write(w)
+	}
+	significant := "否"
+	if crosstab.ChiSquare.Significant {
+		significant = "是"
+	}
+	for _, values := range [][]interface{}{
+		{"卡方值", crosstab.ChiSquare.Statistic},
+		{"自由度", crosstab.ChiSquare.DegreesOfFreedom},
+		{"P值", crosstab.ChiSquare.PValue},
+		{"显著(P<0.05)", significant},
+	} {
+		if err := writeRow(values...); err != nil {
+			return err
+		}
+	}
+	return f.Write(w)
+}
@@ -0,0 +1,59 @@
+package adminService
+
+import (
+	"math"
+	"testing"
+)
+
+// TestChiSquareTest 函数的单元测试
+func TestChiSquareTest(t *testing.T) {
+	tests := []struct {
+		name        string
+		counts      [][]int64
+		statistic   float64
+		df          int
+		pValue      float64
+		significant bool
+	}{
+		{
+			name:        "2x2 significant",
+			counts:      [][]int64{{20, 10}, {5, 15}},
+			statistic:   8.3333,
+			df:          1,
+			pValue:      0.0039,
+			significant: true,
+		},
+		{
+			name:        "independent",
+			counts:      [][]int64{{10, 20}, {20, 40}},
+			statistic:   0,
+			df:          1,
+			pValue:      1,
+			significant: false,
+		},
+		{
+			name:        "2x3 with empty column",
+			counts:      [][]int64{{12, 0, 8}, {6, 0, 14}},
+			statistic:   3.6364,
+			df:          1,
+			pValue:      0.0565,
+			significant: false,
+		},
+		{
+			name:   "single row",
+			counts: [][]int64{{3, 4, 5}},
+			df:     0,
+			pValue: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := chiSquareTest(tt.counts)
+			if math.Abs(result.Statistic-tt.statistic) > 1e-4 || result.DegreesOfFreedom != tt.df ||
+				math.Abs(result.PValue-tt.pValue) > 1e-4 || result.Significant != tt.significant {
+				t.Errorf("chiSquareTest() = %+v, want statistic %v df %v p %v significant %v",
+					result, tt.statistic, tt.df, tt.pValue, tt.significant)
+			}
+		})
+	}
+}
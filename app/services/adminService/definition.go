@@ -0,0 +1,338 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/userService"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 可移植的问卷定义，用于问卷的导入导出
+type SurveyDefinition struct {
+	Title     string     `json:"title" yaml:"title"`         //问卷标题
+	Desc      string     `json:"desc" yaml:"desc"`           //问卷描述
+	Img       string     `json:"img" yaml:"img,omitempty"`   //问卷图片
+	Time      string     `json:"time" yaml:"time"`           //截止时间，RFC3339格式
+	Questions []Question `json:"questions" yaml:"questions"` //题目
+}
+
+// 问卷定义校验错误，Excel文件中Row为行号，JSON/YAML文件中Row为第几道题，0表示问卷信息
+type DefinitionError struct {
+	Row    int    `json:"row"`
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+const (
+	definitionInfoSheet     = "问卷信息"
+	definitionQuestionSheet = "题目"
+)
+
+var definitionHeader = []string{"序号", "题目", "描述", "题型", "必填", "唯一", "其他选项", "正则", "图片"}
+
+var ErrExcelUnsupported = errors.New("Excel格式无法表示该设置")
+
+var questionTypeNames = map[string]int{"单选": 1, "多选": 2, "填空": 3, "简答": 4, "图片": 5, "评分": 6, "NPS": 7, "量表": 8, "矩阵单选": 9, "矩阵多选": 10, "排序": 11}
+
+func ParseDefinitionJSON(data []byte) (SurveyDefinition, error) {
+	var definition SurveyDefinition
+	err := json.Unmarshal(data, &definition)
+	return definition, err
+}
+
+func ParseDefinitionYAML(data []byte) (SurveyDefinition, error) {
+	var definition SurveyDefinition
+	err := yaml.Unmarshal(data, &definition)
+	return definition, err
+}
+
+// 解析Excel格式的问卷定义，"问卷信息"表按行填写标题、描述、图片、截止时间，"题目"表每行一道题，表头之后的列依次为选项
+func ParseDefinitionExcel(r io.Reader) (SurveyDefinition, []DefinitionError, error) {
+	var definition SurveyDefinition
+	definitionErrors := make([]DefinitionError, 0)
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return definition, nil, err
+	}
+	defer f.Close()
+
+	infoRows, err := f.GetRows(definitionInfoSheet)
+	if err != nil {
+		return definition, nil, err
+	}
+	for _, row := range infoRows {
+		if len(row) < 2 {
+			continue
+		}
+		switch strings.TrimSpace(row[0]) {
+		case "标题":
+			definition.Title = row[1]
+		case "描述":
+			definition.Desc = row[1]
+		case "图片":
+			definition.Img = row[1]
+		case "截止时间":
+			definition.Time = row[1]
+		}
+	}
+
+	rows, err := f.GetRows(definitionQuestionSheet)
+	if err != nil {
+		return definition, nil, err
+	}
+	for i, row := range rows {
+		if i == 0 || isEmptyRow(row) {
+			continue
+		}
+		rowNum := i + 1
+		cell := func(col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		question := Question{
+			Subject:     cell(1),
+			Description: cell(2),
+			Reg:         cell(7),
+			Img:         cell(8),
+			Options:     make([]Option, 0),
+		}
+		question.SerialNum = len(definition.Questions) + 1
+		if cell(0) != "" {
+			question.SerialNum, err = strconv.Atoi(cell(0))
+			if err != nil {
+				definitionErrors = append(definitionErrors, DefinitionError{Row: rowNum, Field: "序号", Reason: "序号必须为整数"})
+			}
+		}
+		question.QuestionType = questionTypeNames[cell(3)]
+		if question.QuestionType == 0 {
+			question.QuestionType, _ = strconv.Atoi(cell(3))
+		}
+		for col, field := range []*bool{&question.Required, &question.Unique, &question.OtherOption} {
+			value, ok := parseExcelBool(cell(col + 4))
+			if !ok {
+				definitionErrors = append(definitionErrors, DefinitionError{Row: rowNum, Field: definitionHeader[col+4], Reason: "只能填写是或否"})
+			}
+			*field = value
+		}
+		for col := len(definitionHeader); col < len(row); col++ {
+			if cell(col) == "" {
+				continue
+			}
+			question.Options = append(question.Options, Option{SerialNum: len(question.Options) + 1, Content: cell(col)})
+		}
+		definition.Questions = append(definition.Questions, question)
+		// Excel中以行号报告错误
+		for _, e := range validateQuestion(question) {
+			e.Row = rowNum
+			definitionErrors = append(definitionErrors, e)
+		}
+	}
+	return definition, definitionErrors, nil
+}
+
+// 校验问卷定义，返回所有错误
+func ValidateDefinition(definition SurveyDefinition) []DefinitionError {
+	definitionErrors := make([]DefinitionError, 0)
+	if strings.TrimSpace(definition.Title) == "" {
+		definitionErrors = append(definitionErrors, DefinitionError{Field: "title", Reason: "标题不能为空"})
+	}
+	if _, err := time.Parse(time.RFC3339, definition.Time); err != nil {
+		definitionErrors = append(definitionErrors, DefinitionError{Field: "time", Reason: "截止时间须为RFC3339格式"})
+	}
+	if len(definition.Questions) == 0 {
+		definitionErrors = append(definitionErrors, DefinitionError{Field: "questions", Reason: "问卷至少需要一道题"})
+	}
+	serialNums := make(map[int]bool)
+	for i, question := range definition.Questions {
+		if serialNums[question.SerialNum] {
+			definitionErrors = append(definitionErrors, DefinitionError{Row: i + 1, Field: "serial_num", Reason: "题目序号重复"})
+		}
+		serialNums[question.SerialNum] = true
+		for _, e := range validateQuestion(question) {
+			e.Row = i + 1
+			definitionErrors = append(definitionErrors, e)
+		}
+	}
+	if len(definitionErrors) == 0 {
+		if err := CheckQuestions(definition.Questions); err != nil {
+			definitionErrors = append(definitionErrors, DefinitionError{Field: "questions", Reason: err.Error()})
+		}
+	}
+	return definitionErrors
+}
+
+func validateQuestion(question Question) []DefinitionError {
+	definitionErrors := make([]DefinitionError, 0)
+	if question.SerialNum <= 0 {
+		definitionErrors = append(definitionErrors, DefinitionError{Field: "serial_num", Reason: "题目序号必须为正整数"})
+	}
+	if strings.TrimSpace(question.Subject) == "" {
+		definitionErrors = append(definitionErrors, DefinitionError{Field: "subject", Reason: "题目不能为空"})
+	}
+	if question.QuestionType < 1 || question.QuestionType > 11 {
+		definitionErrors = append(definitionErrors, DefinitionError{Field: "question_type", Reason: "题型不存在"})
+	}
+	if question.Reg != "" {
+		if _, err := regexp.Compile(question.Reg); err != nil {
+			definitionErrors = append(definitionErrors, DefinitionError{Field: "reg", Reason: "正则表达式不合法"})
+		}
+	}
+	if question.QuestionType == 1 || question.QuestionType == 2 || userService.IsMatrixQuestion(question.QuestionType) || question.QuestionType == 11 {
+		if len(question.Options) == 0 {
+			definitionErrors = append(definitionErrors, DefinitionError{Field: "options", Reason: "选择题至少需要一个选项"})
+		}
+		contents := make(map[string]bool)
+		for _, option := range question.Options {
+			if strings.TrimSpace(option.Content) == "" {
+				definitionErrors = append(definitionErrors, DefinitionError{Field: "options", Reason: "选项内容不能为空"})
+			} else if contents[option.Content] {
+				definitionErrors = append(definitionErrors, DefinitionError{Field: "options", Reason: "选项内容重复：" + option.Content})
+			}
+			contents[option.Content] = true
+		}
+	}
+	return definitionErrors
+}
+
+// 导出问卷当前版本的定义
+func GetSurveyDefinition(survey models.Survey) (SurveyDefinition, error) {
+	questions, err := GetSurveyQuestions(survey)
+	if err != nil {
+		return SurveyDefinition{}, err
+	}
+	for i := range questions {
+		questions[i].ID = 0
+		questions[i].StableID = ""
+	}
+	return SurveyDefinition{
+		Title:     survey.Title,
+		Desc:      survey.Desc,
+		Img:       survey.Img,
+		Time:      survey.Deadline.Add(8 * time.Hour).Format(time.RFC3339),
+		Questions: questions,
+	}, nil
+}
+
+// 以Excel格式写出问卷定义，问卷包含Excel无法表示的设置时返回ErrExcelUnsupported，避免导出后再导入时丢失设置
+func WriteDefinitionExcel(w io.Writer, definition SurveyDefinition) error {
+	for _, question := range definition.Questions {
+		if reason := excelUnsupportedReason(question); reason != "" {
+			return fmt.Errorf("第%d题%s：%w", question.SerialNum, reason, ErrExcelUnsupported)
+		}
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", definitionInfoSheet); err != nil {
+		return err
+	}
+	info := [][]interface{}{
+		{"标题", definition.Title},
+		{"描述", definition.Desc},
+		{"图片", definition.Img},
+		{"截止时间", definition.Time},
+	}
+	for i, row := range info {
+		if err := f.SetSheetRow(definitionInfoSheet, fmt.Sprintf("A%d", i+1), &row); err != nil {
+			return err
+		}
+	}
+	if _, err := f.NewSheet(definitionQuestionSheet); err != nil {
+		return err
+	}
+	header := make([]interface{}, 0)
+	for _, title := range definitionHeader {
+		header = append(header, title)
+	}
+	if err := f.SetSheetRow(definitionQuestionSheet, "A1", &header); err != nil {
+		return err
+	}
+	typeNames := make(map[int]string)
+	for name, questionType := range questionTypeNames {
+		typeNames[questionType] = name
+	}
+	for i, question := range definition.Questions {
+		row := []interface{}{
+			question.SerialNum,
+			question.Subject,
+			question.Description,
+			typeNames[question.QuestionType],
+			formatExcelBool(question.Required),
+			formatExcelBool(question.Unique),
+			formatExcelBool(question.OtherOption),
+			question.Reg,
+			question.Img,
+		}
+		for _, option := range question.Options {
+			row = append(row, option.Content)
+		}
+		if err := f.SetSheetRow(definitionQuestionSheet, fmt.Sprintf("A%d", i+2), &row); err != nil {
+			return err
+		}
+	}
+	return f.Write(w)
+}
+
+// 返回题目中Excel格式无法表示的设置，全部可以表示时返回空字符串
+func excelUnsupportedReason(question Question) string {
+	if userService.IsMatrixQuestion(question.QuestionType) {
+		return "为矩阵题"
+	}
+	if question.DisplaySerialNum != 0 || question.DisplayOption != "" {
+		return "设置了显示条件"
+	}
+	if question.Score != 0 || len(question.AcceptedAnswers) > 0 {
+		return "设置了测验分值或答案"
+	}
+	if userService.IsScaleQuestion(question.QuestionType) {
+		min, max, step := userService.GetScaleRange(question.QuestionType, 0, 0, 0)
+		if question.ScaleMin != min || question.ScaleMax != max || question.ScaleStep != step || question.MinLabel != "" || question.MaxLabel != "" {
+			return "设置了量表的范围或标签"
+		}
+	}
+	if question.Block != 0 || question.ShuffleOptions || question.RankLimit != 0 {
+		return "设置了分组、打乱或排序名次"
+	}
+	for _, option := range question.Options {
+		if option.Img != "" || option.SkipTo != 0 || option.Quota != 0 || option.IsCorrect || option.PinLast {
+			return "的选项设置了图片、跳转、名额或测验答案"
+		}
+	}
+	return ""
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseExcelBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "是", "true", "1":
+		return true, true
+	case "", "否", "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func formatExcelBool(value bool) string {
+	if value {
+		return "是"
+	}
+	return "否"
+}
@@ -0,0 +1,258 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/app/services/userService"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 导出格式
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+	ExportXLSX  = "xlsx"
+)
+
+// 导出文件对应的Content-Type
+var ExportContentTypes = map[string]string{
+	ExportCSV:   "text/csv; charset=utf-8",
+	ExportJSONL: "application/x-ndjson",
+	ExportXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// 答卷按行写出，列为当前版本的问题ID，矩阵题每行一列，列名为"问题ID[行标题]"
+type answerWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(serialNum int, time string, contents []string) error
+	Close() error
+}
+
+// 将问卷符合筛选条件的答卷以指定格式写入w，历史版本的答案按题目标识对应到当前问题
+func ExportSurveyAnswers(survey models.Survey, format string, filter AnswerFilter, w io.Writer) error {
+	answerFilter, err := toMongoFilter(survey.ID, filter)
+	if err != nil {
+		return err
+	}
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return err
+	}
+	keys, err := getQuestionKeys(survey.ID)
+	if err != nil {
+		return err
+	}
+	columns := make([]string, 0)
+	index := make(map[string]int)
+	rowIndex := make(map[string]map[string]int)
+	scaleCounts := make(map[int]map[string]int64)
+	for _, question := range questions {
+		key := GetQuestionKey(question)
+		index[key] = len(columns)
+		if userService.IsMatrixQuestion(question.QuestionType) {
+			rowIndex[key] = make(map[string]int)
+			for _, row := range userService.SplitMatrixRows(question.MatrixRows) {
+				rowIndex[key][row] = len(columns)
+				columns = append(columns, fmt.Sprintf("%d[%s]", question.ID, row))
+			}
+			continue
+		}
+		if userService.IsScaleQuestion(question.QuestionType) {
+			scaleCounts[len(columns)] = make(map[string]int64)
+		}
+		columns = append(columns, strconv.Itoa(question.ID))
+	}
+	var writer answerWriter
+	var xw *xlsxAnswerWriter
+	switch format {
+	case ExportCSV:
+		writer = &csvAnswerWriter{w: csv.NewWriter(w)}
+	case ExportJSONL:
+		writer = &jsonlAnswerWriter{w: json.NewEncoder(w)}
+	case ExportXLSX:
+		xw, err = newXLSXAnswerWriter(w)
+		if err != nil {
+			return err
+		}
+		// 关闭时清理excelize使用的临时文件
+		defer xw.f.Close()
+		for i := range columns {
+			_, scale := scaleCounts[i]
+			xw.numeric = append(xw.numeric, scale)
+		}
+		writer = xw
+	default:
+		return errors.New("不支持的导出格式")
+	}
+	if err = writer.WriteHeader(columns); err != nil {
+		return err
+	}
+	serialNum := 0
+	err = mongodbService.IterateAnswerSheetBySurveyID(survey.ID, answerFilter, func(answerSheet mongodbService.AnswerSheet) error {
+		serialNum++
+		contents := make([]string, len(columns))
+		for _, answer := range answerSheet.Answers {
+			if rows, ok := rowIndex[keys[answer.QuestionID]]; ok {
+				for _, m := range answer.Matrix {
+					if i, ok := rows[m.Row]; ok {
+						contents[i] = m.Content
+					}
+				}
+				continue
+			}
+			if i, ok := index[keys[answer.QuestionID]]; ok {
+				contents[i] = answer.Content
+				if counts, ok := scaleCounts[i]; ok && answer.Content != "" {
+					counts[answer.Content]++
+				}
+			}
+		}
+		return writer.WriteRow(serialNum, answerSheet.Time, contents)
+	})
+	if err != nil {
+		return err
+	}
+	// Excel文件附带量表题的统计
+	if xw != nil {
+		for _, question := range questions {
+			if counts, ok := scaleCounts[index[GetQuestionKey(question)]]; ok {
+				xw.scales = append(xw.scales, question)
+				xw.scaleStatistics = append(xw.scaleStatistics, getScaleStatistics(question, counts))
+			}
+		}
+	}
+	return writer.Close()
+}
+
+type csvAnswerWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvAnswerWriter) WriteHeader(columns []string) error {
+	return cw.w.Write(append([]string{"serial_num", "time"}, columns...))
+}
+
+func (cw *csvAnswerWriter) WriteRow(serialNum int, time string, contents []string) error {
+	return cw.w.Write(append([]string{strconv.Itoa(serialNum), time}, contents...))
+}
+
+func (cw *csvAnswerWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonlAnswerWriter struct {
+	w       *json.Encoder
+	columns []string
+}
+
+func (jw *jsonlAnswerWriter) WriteHeader(columns []string) error {
+	jw.columns = columns
+	return nil
+}
+
+func (jw *jsonlAnswerWriter) WriteRow(serialNum int, time string, contents []string) error {
+	answers := make(map[string]string)
+	for i, column := range jw.columns {
+		answers[column] = contents[i]
+	}
+	return jw.w.Encode(map[string]interface{}{
+		"serial_num": serialNum,
+		"time":       time,
+		"answers":    answers,
+	})
+}
+
+func (jw *jsonlAnswerWriter) Close() error {
+	return nil
+}
+
+type xlsxAnswerWriter struct {
+	out             io.Writer
+	f               *excelize.File
+	stream          *excelize.StreamWriter
+	row             int
+	numeric         []bool //以数值写出的列，用于量表题
+	scales          []models.Question
+	scaleStatistics []ScaleStatistics
+}
+
+const scaleStatisticsSheet = "量表统计"
+
+func newXLSXAnswerWriter(w io.Writer) (*xlsxAnswerWriter, error) {
+	f := excelize.NewFile()
+	stream, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &xlsxAnswerWriter{out: w, f: f, stream: stream}, nil
+}
+
+func (xw *xlsxAnswerWriter) WriteHeader(columns []string) error {
+	header := []interface{}{"serial_num", "time"}
+	for _, column := range columns {
+		header = append(header, column)
+	}
+	xw.row = 1
+	return xw.stream.SetRow("A1", header)
+}
+
+func (xw *xlsxAnswerWriter) WriteRow(serialNum int, time string, contents []string) error {
+	xw.row++
+	row := []interface{}{serialNum, time}
+	for i, content := range contents {
+		if i < len(xw.numeric) && xw.numeric[i] {
+			if value, err := strconv.Atoi(content); err == nil {
+				row = append(row, value)
+				continue
+			}
+		}
+		row = append(row, content)
+	}
+	return xw.stream.SetRow(fmt.Sprintf("A%d", xw.row), row)
+}
+
+func (xw *xlsxAnswerWriter) Close() error {
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	if len(xw.scales) > 0 {
+		if err := xw.writeScaleStatistics(); err != nil {
+			return err
+		}
+	}
+	return xw.f.Write(xw.out)
+}
+
+// 每道量表题一行，分布依次写在统计值之后
+func (xw *xlsxAnswerWriter) writeScaleStatistics() error {
+	if _, err := xw.f.NewSheet(scaleStatisticsSheet); err != nil {
+		return err
+	}
+	header := []interface{}{"question_id", "subject", "mean", "median", "nps", "distribution"}
+	if err := xw.f.SetSheetRow(scaleStatisticsSheet, "A1", &header); err != nil {
+		return err
+	}
+	for i, question := range xw.scales {
+		stats := xw.scaleStatistics[i]
+		row := []interface{}{question.ID, question.Subject, stats.Mean, stats.Median, ""}
+		if stats.NPS != nil {
+			row[4] = stats.NPS.Score
+		}
+		for _, d := range stats.Distribution {
+			row = append(row, fmt.Sprintf("%d: %d", d.Value, d.Count))
+		}
+		if err := xw.f.SetSheetRow(scaleStatisticsSheet, fmt.Sprintf("A%d", i+2), &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
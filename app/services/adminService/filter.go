@@ -0,0 +1,68 @@
+package adminService
+
+import (
+	"QA-System/app/services/mongodbService"
+	"errors"
+	"time"
+)
+
+var ErrInvalidFilter = errors.New("筛选的问题不属于该问卷")
+
+// 答卷筛选条件，问题ID为当前版本的问题ID，时间为RFC3339格式
+type AnswerFilter struct {
+	StartTime        time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"` //提交时间下限
+	EndTime          time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`   //提交时间上限
+	OptionQuestionID int       `form:"option_question_id"`                                 //选择题ID
+	Option           string    `form:"option"`                                             //选择题选中的选项
+	TextQuestionID   int       `form:"text_question_id"`                                   //填空题ID
+	Text             string    `form:"text"`                                               //填空题回答包含的文本
+}
+
+// 转换为MongoDB的筛选条件，问题ID扩展为该题在各版本中的ID
+func toMongoFilter(id int, filter AnswerFilter) (mongodbService.AnswerFilter, error) {
+	var answerFilter mongodbService.AnswerFilter
+	// 答卷提交时间以服务器本地时间保存
+	if !filter.StartTime.IsZero() {
+		answerFilter.StartTime = filter.StartTime.Local().Format("2006-01-02 15:04:05")
+	}
+	if !filter.EndTime.IsZero() {
+		answerFilter.EndTime = filter.EndTime.Local().Format("2006-01-02 15:04:05")
+	}
+	if filter.Option == "" && filter.Text == "" {
+		return answerFilter, nil
+	}
+	keys, err := getQuestionKeys(id)
+	if err != nil {
+		return answerFilter, err
+	}
+	if filter.Option != "" {
+		answerFilter.Option = filter.Option
+		answerFilter.OptionQuestionIDs, err = getVersionQuestionIDs(keys, filter.OptionQuestionID)
+		if err != nil {
+			return answerFilter, err
+		}
+	}
+	if filter.Text != "" {
+		answerFilter.Text = filter.Text
+		answerFilter.TextQuestionIDs, err = getVersionQuestionIDs(keys, filter.TextQuestionID)
+		if err != nil {
+			return answerFilter, err
+		}
+	}
+	return answerFilter, nil
+}
+
+// 获取与该问题题目标识相同的各版本问题ID，问题不属于该问卷时返回ErrInvalidFilter
+func getVersionQuestionIDs(keys map[int]string, questionID int) ([]int, error) {
+	key, ok := keys[questionID]
+	if !ok {
+		return nil, ErrInvalidFilter
+	}
+	questionIDs := make([]int, 0)
+	for id, k := range keys {
+		if k == key {
+			questionIDs = append(questionIDs, id)
+		}
+	}
+	return questionIDs, nil
+}
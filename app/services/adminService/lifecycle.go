@@ -0,0 +1,57 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/config/database"
+	"log"
+	"time"
+)
+
+// 允许的问卷状态转换
+var statusTransitions = map[int][]int{
+	models.SurveyDraft:     {models.SurveyOpen, models.SurveyScheduled, models.SurveyArchived},
+	models.SurveyScheduled: {models.SurveyDraft, models.SurveyOpen, models.SurveyClosed},
+	models.SurveyOpen:      {models.SurveyPaused, models.SurveyClosed},
+	models.SurveyPaused:    {models.SurveyOpen, models.SurveyClosed},
+	models.SurveyClosed:    {models.SurveyOpen, models.SurveyArchived},
+	models.SurveyArchived:  {},
+}
+
+func CanTransitStatus(from int, to int) bool {
+	for _, status := range statusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func UpdateSurveyStartTime(id int, startTime time.Time) error {
+	return database.DB.Model(&models.Survey{}).Where("id = ?", id).Update("start_time", startTime).Error
+}
+
+// 按开始时间和截止时间自动发布、结束问卷
+func TransitSurveyStatus(now time.Time) error {
+	err := database.DB.Model(&models.Survey{}).
+		Where("status = ? AND start_time IS NOT NULL AND start_time <= ?", models.SurveyScheduled, now).
+		Update("status", models.SurveyOpen).Error
+	if err != nil {
+		return err
+	}
+	return database.DB.Model(&models.Survey{}).
+		Where("status IN ? AND deadline > ? AND deadline <= ?", []int{models.SurveyOpen, models.SurveyPaused}, time.Time{}, now).
+		Update("status", models.SurveyClosed).Error
+}
+
+// 后台定时检查问卷状态
+func StartSurveyScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := TransitSurveyStatus(now); err != nil {
+				log.Println("TransitSurveyStatusFailed", err)
+			}
+		}
+	}()
+}
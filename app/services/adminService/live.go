@@ -0,0 +1,148 @@
+package adminService
+
+import (
+	"QA-System/app/services/userService"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// 每个订阅者可缓冲的事件数，来不及接收的订阅者会被断开
+const liveBufferSize = 64
+
+// 实时推送的事件，Name为SSE事件名
+type LiveEvent struct {
+	Name string
+	Data interface{}
+}
+
+// 同一问卷的订阅者共用一个Redis订阅，答卷和统计数据只计算一次后分发给各订阅者
+type liveFeed struct {
+	subscribers map[chan LiveEvent]bool
+	cancel      context.CancelFunc
+}
+
+var (
+	liveMutex sync.Mutex
+	liveFeeds = make(map[int]*liveFeed)
+)
+
+// 订阅问卷的新答卷和统计数据，返回事件通道和取消订阅的函数，通道关闭表示推送已结束
+func SubscribeLive(sid int) (<-chan LiveEvent, func(), error) {
+	liveMutex.Lock()
+	defer liveMutex.Unlock()
+	feed, ok := liveFeeds[sid]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		pubsub, err := userService.SubscribeSubmissions(ctx, sid)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		feed = &liveFeed{subscribers: make(map[chan LiveEvent]bool), cancel: cancel}
+		liveFeeds[sid] = feed
+		go feed.run(ctx, sid, pubsub)
+	}
+	events := make(chan LiveEvent, liveBufferSize)
+	feed.subscribers[events] = true
+	unsubscribe := func() {
+		liveMutex.Lock()
+		defer liveMutex.Unlock()
+		if feed.subscribers[events] {
+			delete(feed.subscribers, events)
+			close(events)
+		}
+		// 最后一个订阅者离开时停止订阅
+		if len(feed.subscribers) == 0 && liveFeeds[sid] == feed {
+			delete(liveFeeds, sid)
+			feed.cancel()
+		}
+	}
+	return events, unsubscribe, nil
+}
+
+func (feed *liveFeed) run(ctx context.Context, sid int, pubsub *goredis.PubSub) {
+	defer pubsub.Close()
+	defer feed.stop(sid)
+	survey, err := GetSurveyByID(sid)
+	if err != nil {
+		log.Println("LiveFeedFailed", sid, err)
+		return
+	}
+	messages := pubsub.Channel()
+	// 统计数据每秒最多刷新一次
+	refresh := time.NewTicker(time.Second)
+	defer refresh.Stop()
+	changed := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			answerSheet, err := userService.ParseSubmission(message)
+			if err != nil {
+				log.Println("ParseSubmissionFailed", sid, err)
+				continue
+			}
+			// 问卷修改后产生了新版本，重新获取问卷
+			if answerSheet.Version != survey.Version {
+				survey, err = GetSurveyByID(sid)
+				if err != nil {
+					log.Println("LiveFeedFailed", sid, err)
+					return
+				}
+			}
+			response, err := GetResponse(survey, answerSheet)
+			if err != nil {
+				log.Println("LiveFeedFailed", sid, err)
+				return
+			}
+			feed.broadcast(LiveEvent{Name: "submission", Data: response})
+			changed = true
+		case <-refresh.C:
+			if !changed {
+				continue
+			}
+			statistics, err := GetSurveyStatistics(sid)
+			if err != nil {
+				log.Println("LiveFeedFailed", sid, err)
+				return
+			}
+			feed.broadcast(LiveEvent{Name: "statistics", Data: statistics})
+			changed = false
+		}
+	}
+}
+
+func (feed *liveFeed) broadcast(event LiveEvent) {
+	liveMutex.Lock()
+	defer liveMutex.Unlock()
+	for events := range feed.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(feed.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// 推送结束时关闭所有订阅者的通道
+func (feed *liveFeed) stop(sid int) {
+	liveMutex.Lock()
+	defer liveMutex.Unlock()
+	for events := range feed.subscribers {
+		delete(feed.subscribers, events)
+		close(events)
+	}
+	if liveFeeds[sid] == feed {
+		delete(liveFeeds, sid)
+	}
+	feed.cancel()
+}
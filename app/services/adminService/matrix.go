@@ -0,0 +1,36 @@
+package adminService
+
+import (
+	"QA-System/app/services/mongodbService"
+	"errors"
+	"strings"
+)
+
+// 检查矩阵题的行和列，列为题目的选项，不支持跳转、名额和测验评分
+func checkMatrix(question Question) error {
+	if len(question.MatrixRows) == 0 {
+		return errors.New("矩阵题至少需要一行")
+	}
+	if len(question.Options) == 0 {
+		return errors.New("矩阵题至少需要一个选项")
+	}
+	if question.Score > 0 {
+		return errors.New("矩阵题不能设置测验分值")
+	}
+	rows := make(map[string]bool)
+	for _, row := range question.MatrixRows {
+		if strings.TrimSpace(row) == "" || strings.Contains(row, mongodbService.OptionSeparator) {
+			return errors.New("矩阵题的行标题不能为空或包含分隔符")
+		}
+		if rows[row] {
+			return errors.New("矩阵题的行标题重复：" + row)
+		}
+		rows[row] = true
+	}
+	for _, option := range question.Options {
+		if option.SkipTo != 0 || option.Quota != 0 {
+			return errors.New("矩阵题的选项不能设置跳转或名额")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/config/database"
+	"math"
+)
+
+type QuizStatistics struct {
+	FullScore    int                         `json:"full_score"`   //满分
+	Total        int64                       `json:"total"`        //答卷总数
+	Average      float64                     `json:"average"`      //平均分
+	Highest      int                         `json:"highest"`      //最高分
+	Lowest       int                         `json:"lowest"`       //最低分
+	Distribution []mongodbService.ScoreCount `json:"distribution"` //各得分的人数
+}
+
+type RankItem struct {
+	Rank int `json:"rank"` //名次，得分相同名次相同
+	Response
+	Score int `json:"score"` //得分
+}
+
+func UpdateSurveyQuizMode(id int, quizMode bool) error {
+	return database.DB.Model(&models.Survey{}).Where("id = ?", id).Update("quiz_mode", quizMode).Error
+}
+
+// 统计测验的得分分布
+func GetQuizStatistics(survey models.Survey) (QuizStatistics, error) {
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return QuizStatistics{}, err
+	}
+	distribution, err := mongodbService.CountScoresBySurveyID(survey.ID)
+	if err != nil {
+		return QuizStatistics{}, err
+	}
+	statistics := QuizStatistics{Distribution: distribution}
+	for _, question := range questions {
+		statistics.FullScore += question.Score
+	}
+	var sum int64
+	for i, d := range distribution {
+		if i == 0 {
+			statistics.Lowest = d.Score
+		}
+		statistics.Highest = d.Score
+		statistics.Total += d.Count
+		sum += int64(d.Score) * d.Count
+	}
+	if statistics.Total > 0 {
+		statistics.Average = math.Round(float64(sum)*100/float64(statistics.Total)) / 100
+	}
+	return statistics, nil
+}
+
+// 获取得分排名前limit的答卷
+func GetQuizRanking(survey models.Survey, limit int) ([]RankItem, error) {
+	answerSheets, err := mongodbService.GetTopAnswerSheetsBySurveyID(survey.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	questionIDs, err := getCurrentQuestionIDs(survey)
+	if err != nil {
+		return nil, err
+	}
+	ranking := make([]RankItem, 0)
+	for i, answerSheet := range answerSheets {
+		rank := i + 1
+		if i > 0 && answerSheet.Score == ranking[i-1].Score {
+			rank = ranking[i-1].Rank
+		}
+		ranking = append(ranking, RankItem{
+			Rank:     rank,
+			Response: toResponse(answerSheet, questionIDs),
+			Score:    answerSheet.Score,
+		})
+	}
+	return ranking, nil
+}
@@ -0,0 +1,59 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"errors"
+	"math"
+)
+
+type RankStatistics struct {
+	SerialNum   int     `json:"serial_num"`   //选项序号
+	Content     string  `json:"content"`      //选项内容
+	Count       int64   `json:"count"`        //被排出名次的次数
+	AverageRank float64 `json:"average_rank"` //平均名次，未被排出名次的答卷不计入
+	First       int64   `json:"first"`        //排在第一的次数
+}
+
+// 检查排序题的选项和名次数，排序题的选项不支持跳转、名额和测验评分
+func checkRanking(question Question) error {
+	if len(question.Options) < 2 {
+		return errors.New("排序题至少需要两个选项")
+	}
+	if question.RankLimit < 0 || question.RankLimit > len(question.Options) {
+		return errors.New("排序题的名次数须在0到选项数之间")
+	}
+	if question.Score > 0 {
+		return errors.New("排序题不能设置测验分值")
+	}
+	for _, option := range question.Options {
+		if option.SkipTo != 0 || option.Quota != 0 {
+			return errors.New("排序题的选项不能设置跳转或名额")
+		}
+	}
+	return nil
+}
+
+// 按选项顺序整理排序题的名次统计
+func getRankStatistics(options []models.Option, counts map[string]rankCount) []RankStatistics {
+	stats := make([]RankStatistics, 0)
+	for _, option := range options {
+		c := counts[option.Content]
+		s := RankStatistics{
+			SerialNum: option.SerialNum,
+			Content:   option.Content,
+			Count:     c.count,
+			First:     c.first,
+		}
+		if c.count > 0 {
+			s.AverageRank = math.Round(float64(c.rankSum)*100/float64(c.count)) / 100
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+type rankCount struct {
+	count   int64
+	rankSum int64
+	first   int64
+}
@@ -0,0 +1,148 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/app/services/userService"
+	"QA-System/config/config"
+	"QA-System/config/database"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 单份答卷，答案以问题ID为键，历史版本的答案对应到当前版本的问题ID
+type Response struct {
+	ID      string                         `json:"id"`              //答卷ID
+	Version int                            `json:"version"`         //填写时的问卷版本
+	Time    string                         `json:"time"`            //提交时间
+	Answers map[int]string                 `json:"answers"`         //回答
+	Order   *mongodbService.PresentedOrder `json:"order,omitempty"` //填写者看到的题目和选项顺序
+}
+
+// 分页获取问卷的答卷，每份答卷一条记录
+func GetSurveyResponses(survey models.Survey, num int, size int, filter AnswerFilter) ([]Response, *int64, error) {
+	answerFilter, err := toMongoFilter(survey.ID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	answerSheets, total, err := mongodbService.GetAnswerSheetBySurveyID(survey.ID, num, size, answerFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+	questionIDs, err := getCurrentQuestionIDs(survey)
+	if err != nil {
+		return nil, nil, err
+	}
+	responses := make([]Response, 0)
+	for _, answerSheet := range answerSheets {
+		responses = append(responses, toResponse(answerSheet, questionIDs))
+	}
+	return responses, total, nil
+}
+
+// 按ID获取答卷，ID不合法时返回primitive.ErrInvalidHex
+func GetAnswerSheetByID(id string) (mongodbService.AnswerSheet, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return mongodbService.AnswerSheet{}, primitive.ErrInvalidHex
+	}
+	return mongodbService.GetAnswerSheetByID(objectID)
+}
+
+func GetResponse(survey models.Survey, answerSheet mongodbService.AnswerSheet) (Response, error) {
+	questionIDs, err := getCurrentQuestionIDs(survey)
+	if err != nil {
+		return Response{}, err
+	}
+	return toResponse(answerSheet, questionIDs), nil
+}
+
+// 删除单份答卷及其上传的图片，并释放占用的名额
+func DeleteResponse(survey models.Survey, answerSheet mongodbService.AnswerSheet) error {
+	var questions []models.Question
+	err := database.DB.Where("survey_id = ? AND question_type = ?", answerSheet.SurveyID, 5).Find(&questions).Error
+	if err != nil {
+		return err
+	}
+	imgQuestions := make(map[int]bool)
+	for _, question := range questions {
+		imgQuestions[question.ID] = true
+	}
+	//先删除答卷，删除失败时保留图片
+	err = mongodbService.DeleteAnswerSheetByID(answerSheet.ID)
+	if err != nil {
+		return err
+	}
+	urlHost := config.Config.GetString("url.host")
+	for _, answer := range answerSheet.Answers {
+		if imgQuestions[answer.QuestionID] && answer.Content != "" {
+			_ = os.Remove("./static/" + strings.TrimPrefix(answer.Content, urlHost+"/static/"))
+		}
+	}
+	response, err := GetResponse(survey, answerSheet)
+	if err != nil {
+		return err
+	}
+	data := make([]userService.QuestionsList, 0)
+	for questionID, content := range response.Answers {
+		data = append(data, userService.QuestionsList{QuestionID: questionID, Answer: content})
+	}
+	return userService.ReleaseQuota(survey, data)
+}
+
+// 获取问卷所有版本的问题ID到当前版本问题ID的映射，已删除的问题保留原ID
+func getCurrentQuestionIDs(survey models.Survey) (map[int]int, error) {
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := getQuestionKeys(survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]int)
+	for _, question := range questions {
+		current[GetQuestionKey(question)] = question.ID
+	}
+	questionIDs := make(map[int]int)
+	for id, key := range keys {
+		if currentID, ok := current[key]; ok {
+			questionIDs[id] = currentID
+		} else {
+			questionIDs[id] = id
+		}
+	}
+	return questionIDs, nil
+}
+
+func toResponse(answerSheet mongodbService.AnswerSheet, questionIDs map[int]int) Response {
+	response := Response{
+		ID:      answerSheet.ID.Hex(),
+		Version: answerSheet.Version,
+		Time:    answerSheet.Time,
+		Answers: make(map[int]string),
+	}
+	for _, answer := range answerSheet.Answers {
+		response.Answers[toCurrentQuestionID(questionIDs, answer.QuestionID)] = answer.Content
+	}
+	if answerSheet.Order != nil {
+		response.Order = &mongodbService.PresentedOrder{Questions: make([]int, 0), Options: make([]mongodbService.OptionOrder, 0)}
+		for _, questionID := range answerSheet.Order.Questions {
+			response.Order.Questions = append(response.Order.Questions, toCurrentQuestionID(questionIDs, questionID))
+		}
+		for _, optionOrder := range answerSheet.Order.Options {
+			optionOrder.QuestionID = toCurrentQuestionID(questionIDs, optionOrder.QuestionID)
+			response.Order.Options = append(response.Order.Options, optionOrder)
+		}
+	}
+	return response
+}
+
+func toCurrentQuestionID(questionIDs map[int]int, id int) int {
+	if currentID, ok := questionIDs[id]; ok {
+		return currentID
+	}
+	return id
+}
@@ -3,6 +3,8 @@ package adminService
 import (
 	"QA-System/app/models"
 	"QA-System/config/database"
+
+	"gorm.io/gorm"
 )
 
 func GetUserByName(username string) (models.User, error) {
@@ -11,11 +13,22 @@ func GetUserByName(username string) (models.User, error) {
 	return user, err
 }
 
-func CreatePermission(id int, surveyID int) error {
-	err := database.DB.Create(&models.Manage{UserID: id, SurveyID: surveyID}).Error
+func CreatePermission(id int, surveyID int, role int) error {
+	err := database.DB.Create(&models.Manage{UserID: id, SurveyID: surveyID, Role: role}).Error
+	return err
+}
+
+func UpdatePermission(id int, surveyID int, role int) error {
+	err := database.DB.Model(&models.Manage{}).Where("user_id = ? AND survey_id = ?", id, surveyID).Update("role", role).Error
 	return err
 }
 
+func GetPermission(id int, surveyID int) (models.Manage, error) {
+	var manage models.Manage
+	err := database.DB.Where("user_id = ? AND survey_id = ?", id, surveyID).First(&manage).Error
+	return manage, err
+}
+
 func DeletePermission(id int, surveyID int) error {
 	err := database.DB.Where("user_id = ? AND survey_id = ?", id, surveyID).Delete(&models.Manage{}).Error
 	return err
@@ -25,4 +38,42 @@ func CheckPermission(id int, surveyID int) error {
 	var manage models.Manage
 	err := database.DB.Where("user_id = ? AND survey_id = ?", id, surveyID).First(&manage).Error
 	return err
-}
\ No newline at end of file
+}
+
+// 获取管理员对问卷的权限，超级管理员和问卷所有者拥有全部权限，审计员至少可以查看
+func GetSurveyPermission(user *models.User, survey models.Survey) (int, error) {
+	if user.AdminType == models.AdminTypeSuper || survey.UserID == user.ID {
+		return models.PermissionOwner, nil
+	}
+	permission := models.PermissionNone
+	var manage models.Manage
+	err := database.DB.Where("user_id = ? AND survey_id = ?", user.ID, survey.ID).First(&manage).Error
+	if err == nil {
+		permission = manage.Role
+	} else if err != gorm.ErrRecordNotFound {
+		return models.PermissionNone, err
+	}
+	if user.AdminType == models.AdminTypeAuditor && permission < models.PermissionView {
+		permission = models.PermissionView
+	}
+	return permission, nil
+}
+
+// 问卷协作者
+type Collaborator struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     int    `json:"role"`
+}
+
+// 获取问卷的协作者，不含问卷所有者
+func GetCollaborators(surveyID int) ([]Collaborator, error) {
+	collaborators := make([]Collaborator, 0)
+	err := database.DB.Model(&models.Manage{}).
+		Select("manages.user_id, users.username, manages.role").
+		Joins("JOIN users ON users.id = manages.user_id").
+		Where("manages.survey_id = ?", surveyID).
+		Order("manages.id").
+		Scan(&collaborators).Error
+	return collaborators, err
+}
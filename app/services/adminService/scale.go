@@ -0,0 +1,128 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/userService"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+type ScaleCount struct {
+	Value      int     `json:"value"`      //分值
+	Count      int64   `json:"count"`      //选择人数
+	Percentage float64 `json:"percentage"` //占作答人数的百分比
+}
+
+type ScaleStatistics struct {
+	Min          int          `json:"min"`           //最小值
+	Max          int          `json:"max"`           //最大值
+	Mean         float64      `json:"mean"`          //平均分
+	Median       float64      `json:"median"`        //中位数
+	Distribution []ScaleCount `json:"distribution"`  //各分值的人数
+	NPS          *NPS         `json:"nps,omitempty"` //净推荐值，仅NPS题
+}
+
+type NPS struct {
+	Promoters  int64   `json:"promoters"`  //推荐者，9到10分
+	Passives   int64   `json:"passives"`   //被动者，7到8分
+	Detractors int64   `json:"detractors"` //贬损者，0到6分
+	Score      float64 `json:"score"`      //推荐者百分比减贬损者百分比
+}
+
+// 检查量表题的范围和步长
+func checkScale(question Question) error {
+	if len(question.Options) > 0 {
+		return errors.New("量表题不能设置选项")
+	}
+	if question.Score > 0 {
+		return errors.New("量表题不能设置测验分值")
+	}
+	min, max, step := userService.GetScaleRange(question.QuestionType, question.ScaleMin, question.ScaleMax, question.ScaleStep)
+	if min >= max {
+		return errors.New("量表题的最小值须小于最大值")
+	}
+	if step <= 0 || (max-min)%step != 0 {
+		return errors.New("量表题的步长须为正数且能整除取值范围")
+	}
+	if (max-min)/step > 100 {
+		return errors.New("量表题的刻度不能超过101个")
+	}
+	return nil
+}
+
+// 根据各答案的人数计算量表题的平均分、中位数和分布，不在当前范围内的历史答案也计入
+func getScaleStatistics(question models.Question, counts map[string]int64) ScaleStatistics {
+	min, max, step := userService.GetScaleRange(question.QuestionType, question.ScaleMin, question.ScaleMax, question.ScaleStep)
+	values := make(map[int]int64)
+	for v := min; v <= max; v += step {
+		values[v] = 0
+	}
+	var total, sum int64
+	for content, count := range counts {
+		value, err := strconv.Atoi(content)
+		if err != nil {
+			continue
+		}
+		values[value] += count
+		total += count
+		sum += int64(value) * count
+	}
+	stats := ScaleStatistics{Min: min, Max: max, Distribution: make([]ScaleCount, 0)}
+	for value, count := range values {
+		stats.Distribution = append(stats.Distribution, ScaleCount{
+			Value:      value,
+			Count:      count,
+			Percentage: percentage(count, total),
+		})
+	}
+	sort.Slice(stats.Distribution, func(i, j int) bool {
+		return stats.Distribution[i].Value < stats.Distribution[j].Value
+	})
+	if total > 0 {
+		stats.Mean = math.Round(float64(sum)*100/float64(total)) / 100
+		stats.Median = scaleMedian(stats.Distribution, total)
+	}
+	if question.QuestionType == 7 {
+		nps := &NPS{}
+		for _, d := range stats.Distribution {
+			switch {
+			case d.Value >= 9:
+				nps.Promoters += d.Count
+			case d.Value >= 7:
+				nps.Passives += d.Count
+			default:
+				nps.Detractors += d.Count
+			}
+		}
+		nps.Score = math.Round((percentage(nps.Promoters, total)-percentage(nps.Detractors, total))*100) / 100
+		stats.NPS = nps
+	}
+	return stats
+}
+
+// 按分布计算中位数，人数为偶数时取中间两个值的平均
+func scaleMedian(distribution []ScaleCount, total int64) float64 {
+	var lower, upper int
+	var seen int64
+	found := false
+	for _, d := range distribution {
+		if d.Count == 0 {
+			continue
+		}
+		if !found && seen+d.Count >= (total+1)/2 {
+			lower = d.Value
+			found = true
+		}
+		seen += d.Count
+		if seen >= total/2+1 {
+			upper = d.Value
+			break
+		}
+	}
+	if total%2 == 1 {
+		return float64(lower)
+	}
+	return float64(lower+upper) / 2
+}
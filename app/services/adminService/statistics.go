@@ -0,0 +1,193 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/app/services/userService"
+	"QA-System/config/database"
+	"math"
+)
+
+type OptionStatistics struct {
+	SerialNum  int     `json:"serial_num"` //选项序号，0表示其他
+	Content    string  `json:"content"`    //选项内容
+	Count      int64   `json:"count"`      //选择人数
+	Percentage float64 `json:"percentage"` //占作答人数的百分比
+}
+
+type QuestionStatistics struct {
+	QuestionID   int                `json:"question_id"`
+	SerialNum    int                `json:"serial_num"`        //题目序号
+	Subject      string             `json:"subject"`           //问题
+	QuestionType int                `json:"question_type"`     //问题类型
+	Answered     int64              `json:"answered"`          //作答人数
+	Skipped      int64              `json:"skipped"`           //未作答人数
+	Options      []OptionStatistics `json:"options"`           //选项统计，仅选择题
+	Scale        *ScaleStatistics   `json:"scale,omitempty"`   //量表统计，仅量表题
+	Ranking      []RankStatistics   `json:"ranking,omitempty"` //名次统计，仅排序题
+}
+
+type SurveyStatistics struct {
+	Total     int64                `json:"total"`     //答卷总数
+	Questions []QuestionStatistics `json:"questions"` //各题统计
+}
+
+func GetSurveyStatistics(id int) (SurveyStatistics, error) {
+	survey, err := GetSurveyByID(id)
+	if err != nil {
+		return SurveyStatistics{}, err
+	}
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return SurveyStatistics{}, err
+	}
+	keys, err := getQuestionKeys(id)
+	if err != nil {
+		return SurveyStatistics{}, err
+	}
+	total, err := mongodbService.CountAnswerSheetBySurveyID(id)
+	if err != nil {
+		return SurveyStatistics{}, err
+	}
+	answeredCounts, err := mongodbService.CountAnsweredBySurveyID(id)
+	if err != nil {
+		return SurveyStatistics{}, err
+	}
+	//各版本的答案按题目标识合并统计
+	answered := make(map[string]int64)
+	for _, a := range answeredCounts {
+		answered[keys[a.QuestionID]] += a.Answered
+	}
+	//选择题按选项计数，量表题按分值计数，排序题按名次计数
+	countKeys := make(map[string]bool)
+	rankingKeys := make(map[string]bool)
+	for _, question := range questions {
+		if isChoiceQuestion(question.QuestionType) || userService.IsScaleQuestion(question.QuestionType) {
+			countKeys[GetQuestionKey(question)] = true
+		}
+		if question.QuestionType == 11 {
+			rankingKeys[GetQuestionKey(question)] = true
+		}
+	}
+	countIDs := make([]int, 0)
+	rankingIDs := make([]int, 0)
+	for questionID, key := range keys {
+		if countKeys[key] {
+			countIDs = append(countIDs, questionID)
+		}
+		if rankingKeys[key] {
+			rankingIDs = append(rankingIDs, questionID)
+		}
+	}
+	optionCounts := make(map[string]map[string]int64)
+	if len(countIDs) > 0 {
+		counts, err := mongodbService.CountOptionsBySurveyID(id, countIDs)
+		if err != nil {
+			return SurveyStatistics{}, err
+		}
+		for _, c := range counts {
+			key := keys[c.QuestionID]
+			if optionCounts[key] == nil {
+				optionCounts[key] = make(map[string]int64)
+			}
+			optionCounts[key][c.Option] += c.Count
+		}
+	}
+	rankCounts := make(map[string]map[string]rankCount)
+	if len(rankingIDs) > 0 {
+		counts, err := mongodbService.CountRanksBySurveyID(id, rankingIDs)
+		if err != nil {
+			return SurveyStatistics{}, err
+		}
+		for _, c := range counts {
+			key := keys[c.QuestionID]
+			if rankCounts[key] == nil {
+				rankCounts[key] = make(map[string]rankCount)
+			}
+			r := rankCounts[key][c.Option]
+			r.count += c.Count
+			r.rankSum += c.RankSum
+			r.first += c.First
+			rankCounts[key][c.Option] = r
+		}
+	}
+
+	response := SurveyStatistics{Total: total, Questions: make([]QuestionStatistics, 0)}
+	for _, question := range questions {
+		key := GetQuestionKey(question)
+		q := QuestionStatistics{
+			QuestionID:   question.ID,
+			SerialNum:    question.SerialNum,
+			Subject:      question.Subject,
+			QuestionType: question.QuestionType,
+			Answered:     answered[key],
+			Skipped:      total - answered[key],
+		}
+		if isChoiceQuestion(question.QuestionType) {
+			q.Options, err = getOptionStatistics(question, optionCounts[key], q.Answered)
+			if err != nil {
+				return SurveyStatistics{}, err
+			}
+		}
+		if userService.IsScaleQuestion(question.QuestionType) {
+			scale := getScaleStatistics(question, optionCounts[key])
+			q.Scale = &scale
+		}
+		if question.QuestionType == 11 {
+			var options []models.Option
+			err = database.DB.Where("question_id = ?", question.ID).Order("serial_num").Find(&options).Error
+			if err != nil {
+				return SurveyStatistics{}, err
+			}
+			q.Ranking = getRankStatistics(options, rankCounts[key])
+		}
+		response.Questions = append(response.Questions, q)
+	}
+	return response, nil
+}
+
+// 按选项顺序整理计数，未匹配任何选项的答案计入"其他"
+func getOptionStatistics(question models.Question, counts map[string]int64, answered int64) ([]OptionStatistics, error) {
+	var options []models.Option
+	err := database.DB.Where("question_id = ?", question.ID).Order("serial_num").Find(&options).Error
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]OptionStatistics, 0)
+	matched := make(map[string]bool)
+	for _, option := range options {
+		matched[option.Content] = true
+		stats = append(stats, OptionStatistics{
+			SerialNum:  option.SerialNum,
+			Content:    option.Content,
+			Count:      counts[option.Content],
+			Percentage: percentage(counts[option.Content], answered),
+		})
+	}
+	var other int64
+	for content, count := range counts {
+		if !matched[content] {
+			other += count
+		}
+	}
+	if question.OtherOption || other > 0 {
+		stats = append(stats, OptionStatistics{
+			Content:    "其他",
+			Count:      other,
+			Percentage: percentage(other, answered),
+		})
+	}
+	return stats, nil
+}
+
+func isChoiceQuestion(questionType int) bool {
+	return questionType == 1 || questionType == 2
+}
+
+// 计算百分比，保留两位小数
+func percentage(count int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)*10000/float64(total)) / 100
+}
@@ -3,32 +3,57 @@ package adminService
 import (
 	"QA-System/app/models"
 	"QA-System/app/services/mongodbService"
+	"QA-System/app/services/userService"
 	"QA-System/config/config"
 	"QA-System/config/database"
+	"errors"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Option struct {
-	SerialNum  int    `json:"serial_num"`  //选项序号
-	Content    string `json:"content"`     //选项内容
-	Img 	  string `json:"img"`         //图片
+	SerialNum int    `json:"serial_num" yaml:"serial_num"`           //选项序号
+	Content   string `json:"content" yaml:"content"`                 //选项内容
+	Img       string `json:"img" yaml:"img,omitempty"`               //图片
+	SkipTo    int    `json:"skip_to" yaml:"skip_to,omitempty"`       //选择后跳转到的题目序号 0不跳转 -1结束问卷
+	Quota     int    `json:"quota" yaml:"quota,omitempty"`           //选项名额 0不限
+	Used      int    `json:"-" yaml:"-"`                             //已占用的名额，修改问卷时沿用
+	IsCorrect bool   `json:"is_correct" yaml:"is_correct,omitempty"` //测验模式下是否为正确选项
+	PinLast   bool   `json:"pin_last" yaml:"pin_last,omitempty"`     //打乱选项顺序时固定在末尾
 }
 
 type Question struct {
-	ID           int      `json:"id"`
-	SerialNum    int      `json:"serial_num"`    //题目序号
-	Subject      string   `json:"subject"`       //问题
-	Description  string   `json:"description"`   //问题描述
-	Img          string   `json:"img"`           //图片
-	Required     bool     `json:"required"`      //是否必填
-	Unique       bool     `json:"unique"`        //是否唯一
-	OtherOption  bool     `json:"other_option"`  //是否有其他选项
-	QuestionType int      `json:"question_type"` //问题类型 1单选2多选3填空4简答5图片
-	Reg          string   `json:"reg"`           //正则表达式
-	Options      []Option `json:"options"`       //选项
+	ID               int      `json:"id,omitempty" yaml:"-"`
+	SerialNum        int      `json:"serial_num" yaml:"serial_num"`                           //题目序号
+	Subject          string   `json:"subject" yaml:"subject"`                                 //问题
+	Description      string   `json:"description" yaml:"description,omitempty"`               //问题描述
+	Img              string   `json:"img" yaml:"img,omitempty"`                               //图片
+	Required         bool     `json:"required" yaml:"required"`                               //是否必填
+	Unique           bool     `json:"unique" yaml:"unique"`                                   //是否唯一
+	OtherOption      bool     `json:"other_option" yaml:"other_option"`                       //是否有其他选项
+	QuestionType     int      `json:"question_type" yaml:"question_type"`                     //问题类型 1单选2多选3填空4简答5图片6评分7NPS8量表9矩阵单选10矩阵多选11排序
+	Reg              string   `json:"reg" yaml:"reg,omitempty"`                               //正则表达式
+	DisplaySerialNum int      `json:"display_serial_num" yaml:"display_serial_num,omitempty"` //显示条件依赖的题目序号
+	DisplayOption    string   `json:"display_option" yaml:"display_option,omitempty"`         //依赖题目选中该选项时才显示
+	StableID         string   `json:"stable_id,omitempty" yaml:"-"`                           //题目标识，修改问卷时用于关联原有题目
+	Score            int      `json:"score" yaml:"score,omitempty"`                           //测验模式下的分值
+	AcceptedAnswers  []string `json:"accepted_answers" yaml:"accepted_answers,omitempty"`     //填空题和简答题的参考答案
+	ScaleMin         int      `json:"scale_min" yaml:"scale_min,omitempty"`                   //量表题的最小值
+	ScaleMax         int      `json:"scale_max" yaml:"scale_max,omitempty"`                   //量表题的最大值
+	ScaleStep        int      `json:"scale_step" yaml:"scale_step,omitempty"`                 //量表题的步长
+	MinLabel         string   `json:"min_label" yaml:"min_label,omitempty"`                   //量表题最小值一端的标签
+	MaxLabel         string   `json:"max_label" yaml:"max_label,omitempty"`                   //量表题最大值一端的标签
+	MatrixRows       []string `json:"matrix_rows" yaml:"matrix_rows,omitempty"`               //矩阵题的行标题
+	Block            int      `json:"block" yaml:"block,omitempty"`                           //题目分组，打乱题目顺序时只在连续的同组题目内打乱
+	ShuffleOptions   bool     `json:"shuffle_options" yaml:"shuffle_options,omitempty"`       //是否打乱选项顺序
+	RankLimit        int      `json:"rank_limit" yaml:"rank_limit,omitempty"`                 //排序题只需排出前几名，0表示全部
+	Options          []Option `json:"options" yaml:"options,omitempty"`                       //选项
 }
 
 func GetSurveyByID(id int) (models.Survey, error) {
@@ -37,19 +62,20 @@ func GetSurveyByID(id int) (models.Survey, error) {
 	return survey, err
 }
 
-func CreateSurvey(id int, title string, desc string, img string, questions []Question, status int, time time.Time) error {
+func CreateSurvey(id int, title string, desc string, img string, questions []Question, status int, startTime *time.Time, time time.Time) error {
 	var survey models.Survey
 	survey.UserID = id
 	survey.Title = title
 	survey.Desc = desc
 	survey.Img = img
 	survey.Status = status
+	survey.StartTime = startTime
 	survey.Deadline = time
 	err := database.DB.Create(&survey).Error
 	if err != nil {
 		return err
 	}
-	_,err = createQuestionsAndOptions(questions, survey.ID)
+	_,err = createQuestionsAndOptions(database.DB, questions, survey.ID, survey.Version)
 	return err
 }
 
@@ -59,46 +85,97 @@ func UpdateSurveyStatus(id int, status int) error {
 	return err
 }
 
+func UpdateSurveyShuffle(id int, shuffleQuestions bool) error {
+	return database.DB.Model(&models.Survey{}).Where("id = ?", id).Update("shuffle_questions", shuffleQuestions).Error
+}
+
+func UpdateSurveyLimit(id int, limit int, window int, maxResponses int) error {
+	return database.DB.Model(&models.Survey{}).Where("id = ?", id).Updates(map[string]interface{}{"submit_limit": limit, "limit_window": window, "max_responses": maxResponses}).Error
+}
+
 func UpdateSurvey(id int, title string, desc string, img string, questions []Question, time time.Time) error {
-	//遍历原有问题，删除对应选项
-	var survey models.Survey
-	var oldQuestions []models.Question
-	var old_imgs []string
-	new_imgs := make([]string, 0)
-	//获取原有图片
-	err := database.DB.Where("survey_id = ?", id).Find(&oldQuestions).Error
+	survey, err := GetSurveyByID(id)
 	if err != nil {
 		return err
 	}
-	old_imgs, err = getOldImgs(id, oldQuestions)
+	oldQuestions, err := GetCurrentQuestions(survey)
 	if err != nil {
 		return err
 	}
-	//删除原有问题和选项
+	//沿用原有题目的标识，新增题目生成新标识
+	keys := make(map[string]bool)
 	for _, oldQuestion := range oldQuestions {
-		err = database.DB.Where("question_id = ?", oldQuestion.ID).Delete(&models.Option{}).Error
-		if err != nil {
-			return err
+		keys[GetQuestionKey(oldQuestion)] = true
+	}
+	for i := range questions {
+		if !keys[questions[i].StableID] {
+			questions[i].StableID = uuid.New().String()
 		}
 	}
-	err = database.DB.Where("survey_id = ?", id).Delete(&models.Question{}).Error
+	//沿用原有选项已占用的名额
+	used, err := getOptionsUsed(oldQuestions)
 	if err != nil {
 		return err
 	}
-	//修改问卷信息
-	err = database.DB.Model(&survey).Where("id = ?", id).Updates(map[string]interface{}{"title": title, "desc": desc, "img": img, "deadline": time}).Error
+	for i := range questions {
+		for j := range questions[i].Options {
+			questions[i].Options[j].Used = used[questions[i].StableID+"\x00"+questions[i].Options[j].Content]
+		}
+	}
+	//当前版本已有答卷时创建新版本，否则直接替换当前版本
+	num, err := mongodbService.CountAnswerSheetByVersion(id, survey.Version)
 	if err != nil {
 		return err
 	}
-	new_imgs = append(new_imgs, img)
-	//重新添加问题和选项
-	imgs,err := createQuestionsAndOptions(questions, id)
+	old_imgs, err := getOldImgs(database.DB, id, oldQuestions)
 	if err != nil {
 		return err
 	}
-	new_imgs = append(new_imgs, imgs...)
+	version := survey.Version
+	if num > 0 {
+		version++
+		old_imgs = []string{survey.Img}
+	}
+	//替换题目和查询仍在使用的图片须在同一事务中完成，失败时不删除任何图片
+	var new_imgs []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if num == 0 {
+			//删除原有问题和选项
+			for _, oldQuestion := range oldQuestions {
+				err := tx.Where("question_id = ?", oldQuestion.ID).Delete(&models.Option{}).Error
+				if err != nil {
+					return err
+				}
+			}
+			err := tx.Where("survey_id = ? AND version = ?", id, version).Delete(&models.Question{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		//修改问卷信息
+		err := tx.Model(&survey).Where("id = ?", id).Updates(map[string]interface{}{"title": title, "desc": desc, "img": img, "deadline": time, "version": version}).Error
+		if err != nil {
+			return err
+		}
+		//重新添加问题和选项
+		_, err = createQuestionsAndOptions(tx, questions, id, version)
+		if err != nil {
+			return err
+		}
+		//查询各版本仍在使用的图片
+		var allQuestions []models.Question
+		err = tx.Where("survey_id = ?", id).Find(&allQuestions).Error
+		if err != nil {
+			return err
+		}
+		new_imgs, err = getOldImgs(tx, id, allQuestions)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	//删除各版本都不再使用的图片
 	urlHost := config.Config.GetString("url.host")
-	//删除无用图片
 	for _, old_img := range old_imgs {
 		if !contains(new_imgs, old_img) {
 			_ = os.Remove("./static/" + strings.TrimPrefix(old_img, urlHost+"/static/"))
@@ -107,12 +184,6 @@ func UpdateSurvey(id int, title string, desc string, img string, questions []Que
 	return nil
 }
 
-func UserInManage(uid int, sid int) bool {
-	var survey models.Manage
-	err := database.DB.Where("user_id = ? and survey_id = ?", uid, sid).First(&survey).Error
-	return err == nil
-}
-
 func DeleteSurvey(id int) error {
 	var survey models.Survey
 	var questions []models.Question
@@ -121,7 +192,7 @@ func DeleteSurvey(id int) error {
 		return err
 	}
 	var answerSheets []mongodbService.AnswerSheet
-	answerSheets,_, err = mongodbService.GetAnswerSheetBySurveyID(id,0,0)
+	answerSheets,_, err = mongodbService.GetAnswerSheetBySurveyID(id,0,0,mongodbService.AnswerFilter{})
 	if err != nil {
 		return err
 	}
@@ -170,46 +241,57 @@ type AnswersResonse struct {
 
 
 
-func GetSurveyAnswers(id int, num int, size int) (AnswersResonse, *int64, error) {
-	var answerSheets []mongodbService.AnswerSheet
-	var questions []models.Question
-	data := make([]QuestionAnswers, 0)
-	time := make([]string, 0)
-	var total *int64
-	//获取问题
-	err := database.DB.Where("survey_id = ?", id).Find(&questions).Error
+func GetSurveyAnswers(id int, num int, size int, filter AnswerFilter) (AnswersResonse, *int64, error) {
+	answerFilter, err := toMongoFilter(id, filter)
 	if err != nil {
 		return AnswersResonse{}, nil, err
 	}
-	//初始化data
-	for _, question := range questions {
-		var q QuestionAnswers
-		q.Title = question.Subject
-		q.Answers = make([]string, 0)
-		data = append(data, q)
-	}
 	//获取答卷
-	answerSheets,total, err = mongodbService.GetAnswerSheetBySurveyID(id,num,size)
+	answerSheets, total, err := mongodbService.GetAnswerSheetBySurveyID(id, num, size, answerFilter)
+	if err != nil {
+		return AnswersResonse{}, nil, err
+	}
+	response, err := getAnswersResponse(id, answerSheets)
 	if err != nil {
 		return AnswersResonse{}, nil, err
 	}
-	//填充data
+	return response, total, nil
+}
+
+// 将答卷按当前版本的问题整理为按列排列的数据，各版本的答案按题目标识对应到当前问题
+func getAnswersResponse(id int, answerSheets []mongodbService.AnswerSheet) (AnswersResonse, error) {
+	survey, err := GetSurveyByID(id)
+	if err != nil {
+		return AnswersResonse{}, err
+	}
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return AnswersResonse{}, err
+	}
+	keys, err := getQuestionKeys(id)
+	if err != nil {
+		return AnswersResonse{}, err
+	}
+	data := make([]QuestionAnswers, 0)
+	time := make([]string, 0)
+	index := make(map[string]int)
+	for i, question := range questions {
+		index[GetQuestionKey(question)] = i
+		data = append(data, QuestionAnswers{Title: question.Subject, Answers: make([]string, 0)})
+	}
 	for _, answerSheet := range answerSheets {
 		time = append(time, answerSheet.Time)
+		contents := make([]string, len(questions))
 		for _, answer := range answerSheet.Answers {
-			var question models.Question
-			err = database.DB.Where("id = ?", answer.QuestionID).First(&question).Error
-			if err != nil {
-				return AnswersResonse{}, nil, err
-			}
-			for i, q := range data {
-				if q.Title == question.Subject {
-					data[i].Answers = append(data[i].Answers, answer.Content)
-				}
+			if i, ok := index[keys[answer.QuestionID]]; ok {
+				contents[i] = answer.Content
 			}
 		}
+		for i := range data {
+			data[i].Answers = append(data[i].Answers, contents[i])
+		}
 	}
-	return AnswersResonse{QuestionAnswers: data, Time: time}, total, nil
+	return AnswersResonse{QuestionAnswers: data, Time: time}, nil
 }
 
 func contains(arr []string, str string) bool {
@@ -221,10 +303,26 @@ func contains(arr []string, str string) bool {
 	return false
 }
 
-func getOldImgs(id int, questions []models.Question) ([]string, error) {
+// 获取各选项已占用的名额，以题目标识和选项内容为键
+func getOptionsUsed(questions []models.Question) (map[string]int, error) {
+	used := make(map[string]int)
+	for _, question := range questions {
+		var options []models.Option
+		err := database.DB.Where("question_id = ?", question.ID).Find(&options).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			used[GetQuestionKey(question)+"\x00"+option.Content] = option.Used
+		}
+	}
+	return used, nil
+}
+
+func getOldImgs(db *gorm.DB, id int, questions []models.Question) ([]string, error) {
 	var imgs []string
 	var survey models.Survey
-	err := database.DB.Where("id = ?", id).First(&survey).Error
+	err := db.Where("id = ?", id).First(&survey).Error
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +330,7 @@ func getOldImgs(id int, questions []models.Question) ([]string, error) {
 	for _, question := range questions {
 		imgs = append(imgs, question.Img)
 		var options []models.Option
-		err = database.DB.Where("question_id = ?", question.ID).Find(&options).Error
+		err = db.Where("question_id = ?", question.ID).Find(&options).Error
 		if err != nil {
 			return nil, err
 		}
@@ -278,7 +376,7 @@ func getDelImgs(id int, questions []models.Question, answerSheets []mongodbServi
 	return imgs, nil
 }
 
-func createQuestionsAndOptions(questions []Question,sid int) ([]string,error) {
+func createQuestionsAndOptions(tx *gorm.DB, questions []Question,sid int, version int) ([]string,error) {
 	var imgs []string
 	for _, question := range questions {
 		var q models.Question
@@ -291,8 +389,29 @@ func createQuestionsAndOptions(questions []Question,sid int) ([]string,error) {
 		q.Unique = question.Unique
 		q.OtherOption = question.OtherOption
 		q.QuestionType = question.QuestionType
+		q.Reg = question.Reg
+		q.DisplaySerialNum = question.DisplaySerialNum
+		q.DisplayOption = question.DisplayOption
+		q.Version = version
+		q.StableID = question.StableID
+		q.Score = question.Score
+		q.AcceptedAnswers = strings.Join(question.AcceptedAnswers, mongodbService.OptionSeparator)
+		if userService.IsScaleQuestion(question.QuestionType) {
+			q.ScaleMin, q.ScaleMax, q.ScaleStep = userService.GetScaleRange(question.QuestionType, question.ScaleMin, question.ScaleMax, question.ScaleStep)
+			q.MinLabel = question.MinLabel
+			q.MaxLabel = question.MaxLabel
+		}
+		if userService.IsMatrixQuestion(question.QuestionType) {
+			q.MatrixRows = strings.Join(question.MatrixRows, mongodbService.OptionSeparator)
+		}
+		q.Block = question.Block
+		q.ShuffleOptions = question.ShuffleOptions
+		q.RankLimit = question.RankLimit
+		if q.StableID == "" {
+			q.StableID = uuid.New().String()
+		}
 		imgs = append(imgs, question.Img)
-		err := database.DB.Create(&q).Error
+		err := tx.Create(&q).Error
 		if err != nil {
 			return nil,err
 		}
@@ -302,8 +421,13 @@ func createQuestionsAndOptions(questions []Question,sid int) ([]string,error) {
 			o.QuestionID = q.ID
 			o.SerialNum = option.SerialNum
 			o.Img = option.Img
+			o.SkipTo = option.SkipTo
+			o.Quota = option.Quota
+			o.Used = option.Used
+			o.IsCorrect = option.IsCorrect
+			o.PinLast = option.PinLast
 			imgs = append(imgs, option.Img)
-			err := database.DB.Create(&o).Error
+			err := tx.Create(&o).Error
 			if err != nil {
 				return nil,err
 			}
@@ -312,6 +436,68 @@ func createQuestionsAndOptions(questions []Question,sid int) ([]string,error) {
 	return imgs,nil
 }
 
+// 检查问题中的正则表达式和跳转、显示逻辑是否合法
+func CheckQuestions(questions []Question) error {
+	questionMap := make(map[int]Question)
+	stableIDs := make(map[string]bool)
+	for _, question := range questions {
+		questionMap[question.SerialNum] = question
+		//同一标识出现多次时无法判断哪道题沿用原有题目
+		if question.StableID != "" {
+			if stableIDs[question.StableID] {
+				return errors.New("题目标识重复：" + question.StableID)
+			}
+			stableIDs[question.StableID] = true
+		}
+	}
+	for _, question := range questions {
+		if question.Reg != "" {
+			if _, err := regexp.Compile(question.Reg); err != nil {
+				return err
+			}
+		}
+		if question.Score < 0 {
+			return errors.New("题目分值不能为负数")
+		}
+		if userService.IsScaleQuestion(question.QuestionType) {
+			if err := checkScale(question); err != nil {
+				return err
+			}
+		}
+		if userService.IsMatrixQuestion(question.QuestionType) {
+			if err := checkMatrix(question); err != nil {
+				return err
+			}
+		}
+		if question.QuestionType == 11 {
+			if err := checkRanking(question); err != nil {
+				return err
+			}
+		}
+		for _, option := range question.Options {
+			if option.Quota < 0 {
+				return errors.New("选项名额不能为负数")
+			}
+			if option.SkipTo == 0 || option.SkipTo == -1 {
+				continue
+			}
+			if _, ok := questionMap[option.SkipTo]; !ok || option.SkipTo <= question.SerialNum {
+				return errors.New("跳转目标题目不存在或不在当前题目之后")
+			}
+		}
+		if question.DisplaySerialNum != 0 {
+			dependent, ok := questionMap[question.DisplaySerialNum]
+			if !ok || question.DisplaySerialNum >= question.SerialNum {
+				return errors.New("显示条件依赖的题目不存在或不在当前题目之前")
+			}
+			if dependent.QuestionType != 1 && dependent.QuestionType != 2 {
+				return errors.New("显示条件只能依赖选择题")
+			}
+		}
+	}
+	return nil
+}
+
 func GetAllSurveyByUserID(userId int) ([]interface{}, error) {
 	var surveys []models.Survey
 	err := database.DB.Model(models.Survey{}).Where("user_id = ?", userId).
@@ -402,39 +588,3 @@ func GetManageredSurveyByUserID(userId int) ([]models.Manage, error) {
 	err := database.DB.Model(models.Manage{}).Where("user_id = ?", userId).Order("id DESC").Find(&surveys).Error
 	return surveys, err
 }
-
-func GetAllSurveyAnswers(id int) (AnswersResonse, error) {
-	var data []QuestionAnswers
-	var answerSheets []mongodbService.AnswerSheet
-	var questions []models.Question
-	var time []string
-	err := database.DB.Where("survey_id = ?", id).Find(&questions).Error
-	if err != nil {
-		return AnswersResonse{}, err
-	}
-	for _, question := range questions {
-		var q QuestionAnswers
-		q.Title = question.Subject
-		data = append(data, q)
-	}
-	answerSheets,_, err = mongodbService.GetAnswerSheetBySurveyID(id,0,0)
-	if err != nil {
-		return AnswersResonse{}, err
-	}
-	for _, answerSheet := range answerSheets {
-		time = append(time, answerSheet.Time)
-		for _, answer := range answerSheet.Answers {
-			var question models.Question
-			err = database.DB.Where("id = ?", answer.QuestionID).First(&question).Error
-			if err != nil {
-				return AnswersResonse{}, err
-			}
-			for i, q := range data {
-				if q.Title == question.Subject {
-					data[i].Answers = append(data[i].Answers, answer.Content)
-				}
-			}
-		}
-	}
-	return AnswersResonse{QuestionAnswers: data, Time: time}, nil
-}
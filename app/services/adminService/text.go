@@ -0,0 +1,193 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type TermCount struct {
+	Term  string `json:"term"`  //词元
+	Count int64  `json:"count"` //出现次数
+}
+
+type TextStatistics struct {
+	QuestionID  int         `json:"question_id"`
+	SerialNum   int         `json:"serial_num"`   //题目序号
+	Subject     string      `json:"subject"`      //问题
+	Answered    int64       `json:"answered"`     //作答人数
+	Tokens      []TermCount `json:"tokens"`       //高频词元，英文为单词，中文为相邻两字
+	WordBigrams []TermCount `json:"word_bigrams"` //高频英文相邻词对，中文不统计
+}
+
+// 英文停用词
+var englishStopWords = toSet([]string{
+	"a", "an", "the", "and", "or", "but", "if", "then", "so", "of", "to", "in", "on", "at", "by", "for",
+	"with", "about", "as", "from", "into", "is", "are", "was", "were", "be", "been", "being", "am",
+	"do", "does", "did", "have", "has", "had", "i", "me", "my", "we", "our", "you", "your", "he", "him",
+	"his", "she", "her", "it", "its", "they", "them", "their", "this", "that", "these", "those",
+	"there", "here", "what", "which", "who", "whom", "will", "would", "can", "could", "should", "may",
+	"might", "must", "not", "no", "very", "too", "also", "just", "than", "all", "any", "some",
+})
+
+// 中文停用字，分词时作为分隔符
+var chineseStopChars = toSet(strings.Split("的了是在和也就都而及与着或吗呢吧啊呀哦嗯么之其我你他她它们这那个些把被让给对从向很太更最还又再才", ""))
+
+// 统计问卷当前版本填空题和简答题的高频词元和英文词对，每题各返回前limit个
+func GetTextStatistics(survey models.Survey, limit int, filter AnswerFilter) ([]TextStatistics, error) {
+	questions, err := GetCurrentQuestions(survey)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := getQuestionKeys(survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	answerFilter, err := toMongoFilter(survey.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+	textKeys := make(map[string]bool)
+	for _, question := range questions {
+		if question.QuestionType == 3 || question.QuestionType == 4 {
+			textKeys[GetQuestionKey(question)] = true
+		}
+	}
+	answered := make(map[string]int64)
+	tokens := make(map[string]map[string]int64)
+	bigrams := make(map[string]map[string]int64)
+	err = mongodbService.IterateAnswerSheetBySurveyID(survey.ID, answerFilter, func(answerSheet mongodbService.AnswerSheet) error {
+		for _, answer := range answerSheet.Answers {
+			key := keys[answer.QuestionID]
+			if !textKeys[key] || strings.TrimSpace(answer.Content) == "" {
+				continue
+			}
+			if tokens[key] == nil {
+				tokens[key] = make(map[string]int64)
+				bigrams[key] = make(map[string]int64)
+			}
+			answered[key]++
+			for _, segment := range tokenize(answer.Content) {
+				for i, token := range segment {
+					tokens[key][token]++
+					//中文词元本身已是相邻两字，再组合没有意义
+					if i > 0 && !isHanToken(segment[i-1]) && !isHanToken(token) {
+						bigrams[key][segment[i-1]+" "+token]++
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := make([]TextStatistics, 0)
+	for _, question := range questions {
+		key := GetQuestionKey(question)
+		if !textKeys[key] {
+			continue
+		}
+		response = append(response, TextStatistics{
+			QuestionID:  question.ID,
+			SerialNum:   question.SerialNum,
+			Subject:     question.Subject,
+			Answered:    answered[key],
+			Tokens:      topTerms(tokens[key], limit),
+			WordBigrams: topTerms(bigrams[key], limit),
+		})
+	}
+	return response, nil
+}
+
+// 将文本切分为若干连续片段，词对只在同一片段内的相邻词元之间产生。
+// 英文按单词切分并转为小写；中文不做分词，以停用字和标点断开后按相邻两字切分，单字片段保留为一个词元
+func tokenize(text string) [][]string {
+	segments := make([][]string, 0)
+	var segment []string
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			w := string(word)
+			if !englishStopWords[w] {
+				segment = append(segment, w)
+			}
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			segment = append(segment, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			segment = append(segment, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	flushSegment := func() {
+		flushWord()
+		flushHan()
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+			segment = nil
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			if chineseStopChars[string(r)] {
+				flushSegment()
+				continue
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, unicode.ToLower(r))
+		case unicode.IsSpace(r):
+			flushWord()
+			flushHan()
+		default:
+			flushSegment()
+		}
+	}
+	flushSegment()
+	return segments
+}
+
+// 判断词元是否为中文
+func isHanToken(token string) bool {
+	for _, r := range token {
+		return unicode.Is(unicode.Han, r)
+	}
+	return false
+}
+
+// 按出现次数从高到低排序，次数相同时按字典序
+func topTerms(counts map[string]int64, limit int) []TermCount {
+	terms := make([]TermCount, 0)
+	for term, count := range counts {
+		terms = append(terms, TermCount{Term: term, Count: count})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > limit {
+		terms = terms[:limit]
+	}
+	return terms
+}
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
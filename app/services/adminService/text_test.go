@@ -0,0 +1,81 @@
+package adminService
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTokenize 函数的单元测试
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		segments [][]string
+	}{
+		{
+			name:     "empty",
+			text:     "",
+			segments: [][]string{},
+		},
+		{
+			name:     "english stop words",
+			text:     "The quick brown fox",
+			segments: [][]string{{"quick", "brown", "fox"}},
+		},
+		{
+			name:     "english punctuation",
+			text:     "Good, very GOOD!",
+			segments: [][]string{{"good"}, {"good"}},
+		},
+		{
+			name:     "chinese character bigrams",
+			text:     "老师讲得清楚",
+			segments: [][]string{{"老师", "师讲", "讲得", "得清", "清楚"}},
+		},
+		{
+			name:     "chinese stop chars",
+			text:     "课程很好",
+			segments: [][]string{{"课程"}, {"好"}},
+		},
+		{
+			name:     "chinese punctuation",
+			text:     "内容充实，节奏偏快。",
+			segments: [][]string{{"内容", "容充", "充实"}, {"节奏", "奏偏", "偏快"}},
+		},
+		{
+			name:     "mixed",
+			text:     "Go语言 2024年",
+			segments: [][]string{{"go", "语言", "2024", "年"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments := tokenize(tt.text)
+			if !reflect.DeepEqual(segments, tt.segments) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, segments, tt.segments)
+			}
+		})
+	}
+}
+
+// TestIsHanToken 函数的单元测试
+func TestIsHanToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		han   bool
+	}{
+		{name: "chinese", token: "老师", han: true},
+		{name: "single chinese", token: "好", han: true},
+		{name: "english", token: "quick", han: false},
+		{name: "digits", token: "2024", han: false},
+		{name: "empty", token: "", han: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if han := isHanToken(tt.token); han != tt.han {
+				t.Errorf("isHanToken(%q) = %v, want %v", tt.token, han, tt.han)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/config/database"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 令牌明文前缀，便于在日志和代码中识别泄露的令牌
+const tokenPrefix = "qat_"
+
+var ErrTokenInvalid = errors.New("令牌无效或已过期")
+
+// 创建令牌，返回仅此一次可见的令牌明文
+func CreateToken(uid int, name string, scopes []string, expiresAt time.Time) (string, models.Token, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", models.Token{}, err
+	}
+	raw := tokenPrefix + hex.EncodeToString(b)
+	token := models.Token{
+		UserID:    uid,
+		Name:      name,
+		Prefix:    raw[:len(tokenPrefix)+8],
+		TokenHash: hashToken(raw),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	err := database.DB.Create(&token).Error
+	return raw, token, err
+}
+
+func GetTokensByUserID(uid int) ([]models.Token, error) {
+	tokens := make([]models.Token, 0)
+	err := database.DB.Where("user_id = ?", uid).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// 删除管理员自己的令牌，令牌不存在时返回gorm.ErrRecordNotFound
+func DeleteToken(uid int, id int) error {
+	result := database.DB.Where("id = ? AND user_id = ?", id, uid).Delete(&models.Token{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// 根据令牌明文获取令牌及其所属管理员，令牌不存在或已过期时返回ErrTokenInvalid
+func GetTokenUser(raw string) (*models.User, models.Token, error) {
+	var token models.Token
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return nil, token, ErrTokenInvalid
+	}
+	err := database.DB.Where("token_hash = ?", hashToken(raw)).First(&token).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, token, ErrTokenInvalid
+	} else if err != nil {
+		return nil, token, err
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		return nil, token, ErrTokenInvalid
+	}
+	user, err := GetAdminByID(token.UserID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, token, ErrTokenInvalid
+	} else if err != nil {
+		return nil, token, err
+	}
+	// 记录使用时间失败不影响认证
+	_ = database.DB.Model(&models.Token{}).Where("id = ?", token.ID).Update("last_used_at", time.Now()).Error
+	return user, token, nil
+}
+
+// 判断令牌是否包含授权范围
+func HasScope(token models.Token, scope string) bool {
+	for _, s := range strings.Split(token.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// 令牌为高熵随机值，使用SHA-256保存即可，无需加盐
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,36 @@
+package adminService
+
+import (
+	"QA-System/app/models"
+	"QA-System/config/database"
+	"strconv"
+)
+
+// 获取题目跨版本不变的标识，引入版本前创建的题目以ID作为标识
+func GetQuestionKey(question models.Question) string {
+	if question.StableID != "" {
+		return question.StableID
+	}
+	return strconv.Itoa(question.ID)
+}
+
+// 获取问卷当前版本的问题
+func GetCurrentQuestions(survey models.Survey) ([]models.Question, error) {
+	var questions []models.Question
+	err := database.DB.Where("survey_id = ? AND version = ?", survey.ID, survey.Version).Order("serial_num").Find(&questions).Error
+	return questions, err
+}
+
+// 获取问卷所有版本中问题ID到题目标识的映射
+func getQuestionKeys(id int) (map[int]string, error) {
+	var questions []models.Question
+	err := database.DB.Where("survey_id = ?", id).Find(&questions).Error
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[int]string)
+	for _, question := range questions {
+		keys[question.ID] = GetQuestionKey(question)
+	}
+	return keys, nil
+}
@@ -0,0 +1,52 @@
+package mongodbService
+
+import (
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 答卷筛选条件，零值表示不筛选
+type AnswerFilter struct {
+	StartTime         string // 提交时间下限，格式为2006-01-02 15:04:05
+	EndTime           string // 提交时间上限，格式为2006-01-02 15:04:05
+	OptionQuestionIDs []int  // 选择题在各版本中的问题ID
+	Option            string // 选择题选中的选项
+	TextQuestionIDs   []int  // 填空题在各版本中的问题ID
+	Text              string // 填空题回答包含的文本
+}
+
+// 生成MongoDB查询条件
+func (f AnswerFilter) toBson(surveyID int) bson.M {
+	filter := bson.M{"surveyid": surveyID}
+	timeFilter := bson.M{}
+	if f.StartTime != "" {
+		timeFilter["$gte"] = f.StartTime
+	}
+	if f.EndTime != "" {
+		timeFilter["$lte"] = f.EndTime
+	}
+	if len(timeFilter) > 0 {
+		filter["time"] = timeFilter
+	}
+	conditions := bson.A{}
+	if f.Option != "" {
+		// 多选题答案以分隔符连接，需完整匹配其中一个选项
+		separator := regexp.QuoteMeta(OptionSeparator)
+		pattern := "(^|" + separator + ")" + regexp.QuoteMeta(f.Option) + "($|" + separator + ")"
+		conditions = append(conditions, bson.M{"answers": bson.M{"$elemMatch": bson.M{
+			"questionid": bson.M{"$in": f.OptionQuestionIDs},
+			"content":    bson.M{"$regex": pattern},
+		}}})
+	}
+	if f.Text != "" {
+		conditions = append(conditions, bson.M{"answers": bson.M{"$elemMatch": bson.M{
+			"questionid": bson.M{"$in": f.TextQuestionIDs},
+			"content":    bson.M{"$regex": regexp.QuoteMeta(f.Text), "$options": "i"},
+		}}})
+	}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
+	}
+	return filter
+}
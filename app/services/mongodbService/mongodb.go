@@ -3,39 +3,57 @@ package mongodbService
 import (
 	"QA-System/config/database"
 	"context"
-	"log"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Answer struct {
-	QuestionID int    `json:"question_id"` //问题ID
-	SerialNum  int    `json:"serial_num"`  //问题序号
-	Subject    string `json:"subject"`     //问题
-	Content    string `json:"content"`     //回答内容
+	QuestionID int            `json:"question_id"`       //问题ID
+	SerialNum  int            `json:"serial_num"`        //问题序号
+	Subject    string         `json:"subject"`           //问题
+	Content    string         `json:"content"`           //回答内容，矩阵题为各行答案的文本形式，排序题为以┋连接的选项
+	Matrix     []MatrixAnswer `json:"matrix,omitempty"`  //矩阵题各行的答案
+	Ranking    []string       `json:"ranking,omitempty"` //排序题按名次排列的选项
+}
+
+type MatrixAnswer struct {
+	Row     string `json:"row"`     //行标题
+	Content string `json:"content"` //该行选中的选项，多选以┋分隔
 }
 
 type AnswerSheet struct {
-	SurveyID int      `json:"survey_id"` //问卷ID
-	Time     string   `json:"time"`      //回答时间
-	Answers  []Answer `json:"answers"`   //回答
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"` //答卷ID
+	SurveyID int                `json:"survey_id"`               //问卷ID
+	Version  int                `json:"version"`                 //填写时的问卷版本
+	Time     string             `json:"time"`                    //回答时间
+	Answers  []Answer           `json:"answers"`                 //回答
+	Score    int                `json:"score"`                   //测验模式下的得分
+	Order    *PresentedOrder    `json:"order,omitempty"`         //打乱顺序时填写者看到的顺序
+}
+
+type PresentedOrder struct {
+	Questions []int         `json:"questions"` //题目ID的顺序
+	Options   []OptionOrder `json:"options"`   //打乱选项的题目的选项顺序
+}
+
+type OptionOrder struct {
+	QuestionID int   `json:"question_id"`
+	Options    []int `json:"options"` //选项序号的顺序
 }
 
 func SaveAnswerSheet(answerSheet AnswerSheet) error {
 	_, err := database.MDB.InsertOne(context.Background(), answerSheet)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return nil
+	return err
 }
 
-func GetAnswerSheetBySurveyID(surveyID int, pageNum int, pageSize int) ([]AnswerSheet, *int64, error) {
+func GetAnswerSheetBySurveyID(surveyID int, pageNum int, pageSize int, answerFilter AnswerFilter) ([]AnswerSheet, *int64, error) {
 	var answerSheets []AnswerSheet
-	filter := bson.M{"surveyid": surveyID}
+	filter := answerFilter.toBson(surveyID)
 
 	// 设置总记录数查询过滤条件
-	countFilter := bson.M{"surveyid": surveyID}
+	countFilter := answerFilter.toBson(surveyID)
 
 	// 设置总记录数查询选项
 	countOpts := options.Count()
@@ -47,7 +65,7 @@ func GetAnswerSheetBySurveyID(surveyID int, pageNum int, pageSize int) ([]Answer
 	}
 
 	// 设置分页查询选项
-	opts := options.Find()
+	opts := options.Find().SetSort(bson.M{"_id": 1})
 	if pageNum != 0 && pageSize != 0 {
 		opts.SetSkip(int64((pageNum - 1) * pageSize)) // 计算要跳过的文档数
 		opts.SetLimit(int64(pageSize))             // 设置返回的文档数
@@ -75,6 +93,39 @@ func GetAnswerSheetBySurveyID(surveyID int, pageNum int, pageSize int) ([]Answer
 	return answerSheets, &total, nil
 }
 
+func GetAnswerSheetByID(id primitive.ObjectID) (AnswerSheet, error) {
+	var answerSheet AnswerSheet
+	err := database.MDB.FindOne(context.Background(), bson.M{"_id": id}).Decode(&answerSheet)
+	return answerSheet, err
+}
+
+func DeleteAnswerSheetByID(id primitive.ObjectID) error {
+	_, err := database.MDB.DeleteOne(context.Background(), bson.M{"_id": id})
+	return err
+}
+
+// 按提交顺序逐条读取问卷的答卷，避免一次性将全部答卷加载到内存
+func IterateAnswerSheetBySurveyID(surveyID int, answerFilter AnswerFilter, fn func(answerSheet AnswerSheet) error) error {
+	filter := answerFilter.toBson(surveyID)
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cur, err := database.MDB.Find(context.Background(), filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.Background())
+
+	for cur.Next(context.Background()) {
+		var answerSheet AnswerSheet
+		if err := cur.Decode(&answerSheet); err != nil {
+			return err
+		}
+		if err := fn(answerSheet); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
 func DeleteAnswerSheetBySurveyID(surveyID int) error {
 	filter := bson.M{"surveyid": surveyID}
 	// 删除所有满足条件的文档
@@ -69,7 +69,7 @@ func TestGetAnswerSheetBySurveyID(t *testing.T) {
 			database.MongodbInit()
 
 			// 调用 GetAnswerSheetBySurveyID 函数获取答卷表
-			_, _, err := GetAnswerSheetBySurveyID(tt.surveyID, tt.pageNum, tt.pageSize)
+			_, _, err := GetAnswerSheetBySurveyID(tt.surveyID, tt.pageNum, tt.pageSize, AnswerFilter{})
 
 			if (err != nil) != (tt.expectError != nil){
 				t.Errorf("测试用例 %q 失败，期望错误：%v，实际得到：%v", tt.name, tt.expectError, err)
@@ -108,6 +108,38 @@ func TestDeleteAnswerSheetBySurveyID(t *testing.T) {
 	}
 }
 
+// TestCountOptionsBySurveyID 函数的单元测试
+func TestCountOptionsBySurveyID(t *testing.T) {
+	tests := []struct {
+		name        string
+		surveyID    int
+		questionIDs []int
+		expectError error
+	}{
+		{
+			name:        "有效的调查",
+			surveyID:    1,
+			questionIDs: []int{1, 2},
+			expectError: nil,
+		},
+		// 在这里添加更多的测试用例
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 初始化 MongoDB
+			database.MongodbInit()
+
+			// 统计选项选择次数
+			_, err := CountOptionsBySurveyID(tt.surveyID, tt.questionIDs)
+
+			if (err != nil) != (tt.expectError != nil) {
+				t.Errorf("测试用例 %q 失败，期望错误：%v，实际得到：%v", tt.name, tt.expectError, err)
+			}
+		})
+	}
+}
+
 // 基准测试
 // BenchmarkSaveAnswerSheet 函数的并发基准测试
 func BenchmarkSaveAnswerSheet(b *testing.B) {
@@ -150,7 +182,7 @@ func BenchmarkGetAnswerSheetBySurveyID(b *testing.B) {
 		// 每个并发测试独立地运行 b.N 次
 		for pb.Next() {
 			// 调用 GetAnswerSheetBySurveyID 函数获取答卷表
-			_, _, err := GetAnswerSheetBySurveyID(1, 50, 100)
+			_, _, err := GetAnswerSheetBySurveyID(1, 50, 100, AnswerFilter{})
 			if err != nil {
 				b.Errorf("GetAnswerSheetBySurveyID() error = %v", err)
 			}
@@ -204,7 +236,7 @@ func ExampleGetAnswerSheetBySurveyID() {
 	database.MongodbInit()
 
 	// 调用 GetAnswerSheetBySurveyID 函数获取答卷表
-	_, _, err := GetAnswerSheetBySurveyID(1, 50, 100)
+	_, _, err := GetAnswerSheetBySurveyID(1, 50, 100, AnswerFilter{})
 	if err != nil {
 	}
 }
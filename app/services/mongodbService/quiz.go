@@ -0,0 +1,70 @@
+package mongodbService
+
+import (
+	"QA-System/config/database"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScoreCount struct {
+	Score int   `json:"score"` //得分
+	Count int64 `json:"count"` //人数
+}
+
+// 统计问卷各得分的人数，按得分从低到高排列
+func CountScoresBySurveyID(surveyID int) ([]ScoreCount, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"surveyid": surveyID}},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$score", 0}},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	}
+	cur, err := database.MDB.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+
+	counts := make([]ScoreCount, 0)
+	for cur.Next(context.Background()) {
+		var result struct {
+			Score int   `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cur.Decode(&result); err != nil {
+			return nil, err
+		}
+		counts = append(counts, ScoreCount{Score: result.Score, Count: result.Count})
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// 按得分从高到低获取答卷，得分相同时先提交的在前
+func GetTopAnswerSheetsBySurveyID(surveyID int, limit int) ([]AnswerSheet, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cur, err := database.MDB.Find(context.Background(), bson.M{"surveyid": surveyID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+
+	answerSheets := make([]AnswerSheet, 0)
+	for cur.Next(context.Background()) {
+		var answerSheet AnswerSheet
+		if err := cur.Decode(&answerSheet); err != nil {
+			return nil, err
+		}
+		answerSheets = append(answerSheets, answerSheet)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return answerSheets, nil
+}
@@ -0,0 +1,177 @@
+package mongodbService
+
+import (
+	"QA-System/config/database"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 多选题答案中各选项之间的分隔符
+const OptionSeparator = "┋"
+
+type OptionCount struct {
+	QuestionID int    `json:"question_id"` //问题ID
+	Option     string `json:"option"`      //选项内容
+	Count      int64  `json:"count"`       //选择次数
+}
+
+type AnsweredCount struct {
+	QuestionID int   `json:"question_id"` //问题ID
+	Answered   int64 `json:"answered"`    //作答数量
+}
+
+// 统计选择题每个选项被选择的次数，多选题答案按分隔符拆分后分别计数
+func CountOptionsBySurveyID(surveyID int, questionIDs []int) ([]OptionCount, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"surveyid": surveyID}},
+		bson.M{"$unwind": "$answers"},
+		bson.M{"$match": bson.M{
+			"answers.questionid": bson.M{"$in": questionIDs},
+			"answers.content":    bson.M{"$ne": ""},
+		}},
+		bson.M{"$project": bson.M{
+			"questionid": "$answers.questionid",
+			"option":     bson.M{"$split": bson.A{"$answers.content", OptionSeparator}},
+		}},
+		bson.M{"$unwind": "$option"},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"questionid": "$questionid", "option": "$option"},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+	cur, err := database.MDB.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+
+	counts := make([]OptionCount, 0)
+	for cur.Next(context.Background()) {
+		var result struct {
+			ID struct {
+				QuestionID int    `bson:"questionid"`
+				Option     string `bson:"option"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cur.Decode(&result); err != nil {
+			return nil, err
+		}
+		counts = append(counts, OptionCount{
+			QuestionID: result.ID.QuestionID,
+			Option:     result.ID.Option,
+			Count:      result.Count,
+		})
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// 统计每个问题的有效作答数量（答案非空）
+func CountAnsweredBySurveyID(surveyID int) ([]AnsweredCount, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"surveyid": surveyID}},
+		bson.M{"$unwind": "$answers"},
+		bson.M{"$group": bson.M{
+			"_id": "$answers.questionid",
+			"answered": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$ne": bson.A{"$answers.content", ""}}, 1, 0},
+			}},
+		}},
+	}
+	cur, err := database.MDB.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+
+	counts := make([]AnsweredCount, 0)
+	for cur.Next(context.Background()) {
+		var result struct {
+			QuestionID int   `bson:"_id"`
+			Answered   int64 `bson:"answered"`
+		}
+		if err := cur.Decode(&result); err != nil {
+			return nil, err
+		}
+		counts = append(counts, AnsweredCount{QuestionID: result.QuestionID, Answered: result.Answered})
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// 统计问卷的答卷总数
+func CountAnswerSheetBySurveyID(surveyID int) (int64, error) {
+	return database.MDB.CountDocuments(context.Background(), bson.M{"surveyid": surveyID})
+}
+
+// 统计问卷某一版本的答卷数量
+func CountAnswerSheetByVersion(surveyID int, version int) (int64, error) {
+	filter := bson.M{"surveyid": surveyID, "version": version}
+	if version == 0 {
+		// 引入版本前的答卷没有版本字段
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	return database.MDB.CountDocuments(context.Background(), filter)
+}
+
+type RankCount struct {
+	QuestionID int    `json:"question_id"` //问题ID
+	Option     string `json:"option"`      //选项内容
+	Count      int64  `json:"count"`       //被排序的次数
+	RankSum    int64  `json:"rank_sum"`    //名次之和，名次从1开始
+	First      int64  `json:"first"`       //排在第一的次数
+}
+
+// 统计排序题每个选项的名次
+func CountRanksBySurveyID(surveyID int, questionIDs []int) ([]RankCount, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"surveyid": surveyID}},
+		bson.M{"$unwind": "$answers"},
+		bson.M{"$match": bson.M{"answers.questionid": bson.M{"$in": questionIDs}}},
+		bson.M{"$unwind": bson.M{"path": "$answers.ranking", "includeArrayIndex": "rank"}},
+		bson.M{"$group": bson.M{
+			"_id":     bson.M{"questionid": "$answers.questionid", "option": "$answers.ranking"},
+			"count":   bson.M{"$sum": 1},
+			"ranksum": bson.M{"$sum": bson.M{"$add": bson.A{"$rank", 1}}},
+			"first":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$rank", 0}}, 1, 0}}},
+		}},
+	}
+	cur, err := database.MDB.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.Background())
+
+	counts := make([]RankCount, 0)
+	for cur.Next(context.Background()) {
+		var result struct {
+			ID struct {
+				QuestionID int    `bson:"questionid"`
+				Option     string `bson:"option"`
+			} `bson:"_id"`
+			Count   int64 `bson:"count"`
+			RankSum int64 `bson:"ranksum"`
+			First   int64 `bson:"first"`
+		}
+		if err := cur.Decode(&result); err != nil {
+			return nil, err
+		}
+		counts = append(counts, RankCount{
+			QuestionID: result.ID.QuestionID,
+			Option:     result.ID.Option,
+			Count:      result.Count,
+			RankSum:    result.RankSum,
+			First:      result.First,
+		})
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
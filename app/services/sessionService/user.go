@@ -20,7 +20,17 @@ func SetUserSession(c *gin.Context, user *models.User) error {
 	return webSession.Save()
 }
 
+// 通过API令牌认证的管理员，仅在当前请求中有效
+const tokenUserKey = "token_user"
+
+func SetTokenUser(c *gin.Context, user *models.User) {
+	c.Set(tokenUserKey, user)
+}
+
 func GetUserSession(c *gin.Context) (*models.User, error) {
+	if user, ok := c.Get(tokenUserKey); ok {
+		return user.(*models.User), nil
+	}
 	webSession := sessions.Default(c)
 	id := webSession.Get("id")
 	if id == nil {
@@ -0,0 +1,76 @@
+package userService
+
+import (
+	"QA-System/config/config"
+	"QA-System/config/redis"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Draft struct {
+	SurveyID      int             `json:"survey_id"`      //问卷ID
+	QuestionsList []QuestionsList `json:"questions_list"` //已填写的答案
+	Time          string          `json:"time"`           //保存时间
+}
+
+func getDraftKey(token string) string {
+	return "qa:draft:" + token
+}
+
+// 草稿有效期，默认7天
+func getDraftExpiration() time.Duration {
+	if config.Config.IsSet("draft.expire") {
+		return time.Duration(config.Config.GetInt("draft.expire")) * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// 保存草稿，返回过期时间
+func SaveDraft(token string, draft Draft) (time.Time, error) {
+	draft.Time = time.Now().Format("2006-01-02 15:04:05")
+	value, err := json.Marshal(draft)
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiration := getDraftExpiration()
+	err = redis.RedisClient.Set(context.Background(), getDraftKey(token), value, expiration).Err()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(expiration), nil
+}
+
+// 获取草稿，草稿不存在或已过期时返回redis.Nil
+func GetDraft(token string) (Draft, error) {
+	var draft Draft
+	value, err := redis.RedisClient.Get(context.Background(), getDraftKey(token)).Bytes()
+	if err != nil {
+		return draft, err
+	}
+	err = json.Unmarshal(value, &draft)
+	return draft, err
+}
+
+func DeleteDraft(token string) error {
+	return redis.RedisClient.Del(context.Background(), getDraftKey(token)).Err()
+}
+
+// 用本次提交的答案覆盖草稿中相同问题的答案
+func MergeDraft(draft []QuestionsList, data []QuestionsList) []QuestionsList {
+	index := make(map[int]int)
+	merged := make([]QuestionsList, 0, len(draft)+len(data))
+	for _, q := range draft {
+		index[q.QuestionID] = len(merged)
+		merged = append(merged, q)
+	}
+	for _, q := range data {
+		if i, ok := index[q.QuestionID]; ok {
+			merged[i] = q
+			continue
+		}
+		index[q.QuestionID] = len(merged)
+		merged = append(merged, q)
+	}
+	return merged
+}
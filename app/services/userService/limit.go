@@ -0,0 +1,68 @@
+package userService
+
+import (
+	"QA-System/app/models"
+	"QA-System/config/config"
+	"QA-System/config/redis"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrRespondentIdentity = errors.New("缺少有效的填写者身份")
+
+// 校验接入方签发的填写者身份令牌，格式为"身份.签名"，签名为使用respondent.secret对身份计算的HMAC-SHA256，
+// 未配置密钥或签名不符时返回ErrRespondentIdentity
+func VerifyRespondentToken(token string) (string, error) {
+	secret := config.Config.GetString("respondent.secret")
+	i := strings.LastIndex(token, ".")
+	if secret == "" || i <= 0 {
+		return "", ErrRespondentIdentity
+	}
+	identity := token[:i]
+	signature, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return "", ErrRespondentIdentity
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(identity))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", ErrRespondentIdentity
+	}
+	return identity, nil
+}
+
+func getSubmitLimitKey(survey models.Survey, respondent string) string {
+	return fmt.Sprintf("qa:submit:%d:%d:%s", survey.ID, survey.SubmitLimit, respondent)
+}
+
+// 占用提交名额，返回false表示该填写者已经提交过
+func AcquireSubmitLimit(survey models.Survey, respondent string) (bool, error) {
+	if survey.SubmitLimit == models.SubmitLimitNone {
+		return true, nil
+	}
+	var expiration time.Duration
+	if survey.SubmitLimit == models.SubmitLimitIP {
+		expiration = time.Duration(survey.LimitWindow) * time.Minute
+	} else if !survey.Deadline.IsZero() {
+		// 问卷截止后记录不再需要保留
+		expiration = time.Until(survey.Deadline) + 24*time.Hour
+	}
+	if expiration < 0 {
+		expiration = 0
+	}
+	return redis.RedisClient.SetNX(context.Background(), getSubmitLimitKey(survey, respondent), time.Now().Unix(), expiration).Result()
+}
+
+// 提交失败时释放名额
+func ReleaseSubmitLimit(survey models.Survey, respondent string) error {
+	if survey.SubmitLimit == models.SubmitLimitNone {
+		return nil
+	}
+	return redis.RedisClient.Del(context.Background(), getSubmitLimitKey(survey, respondent)).Err()
+}
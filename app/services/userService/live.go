@@ -0,0 +1,40 @@
+package userService
+
+import (
+	"QA-System/app/services/mongodbService"
+	"QA-System/config/redis"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+func getLiveChannel(sid int) string {
+	return fmt.Sprintf("qa:live:%d", sid)
+}
+
+// 发布新提交的答卷，由订阅该问卷的各实例推送给实时大屏
+func PublishSubmission(answerSheet mongodbService.AnswerSheet) error {
+	message, err := json.Marshal(answerSheet)
+	if err != nil {
+		return err
+	}
+	return redis.RedisClient.Publish(context.Background(), getLiveChannel(answerSheet.SurveyID), message).Err()
+}
+
+// 订阅问卷新提交的答卷，返回前确认订阅已建立，调用方负责关闭
+func SubscribeSubmissions(ctx context.Context, sid int) (*goredis.PubSub, error) {
+	pubsub := redis.RedisClient.Subscribe(ctx, getLiveChannel(sid))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	return pubsub, nil
+}
+
+func ParseSubmission(message *goredis.Message) (mongodbService.AnswerSheet, error) {
+	var answerSheet mongodbService.AnswerSheet
+	err := json.Unmarshal([]byte(message.Payload), &answerSheet)
+	return answerSheet, err
+}
@@ -0,0 +1,55 @@
+package userService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"sort"
+	"strings"
+)
+
+// 根据答案计算每个问题是否应当显示，被跳过或不满足显示条件的问题为不显示
+func GetVisibleQuestions(questions []models.Question, options map[int][]models.Option, answers map[int]string) map[int]bool {
+	sorted := make([]models.Question, len(questions))
+	copy(sorted, questions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].SerialNum < sorted[j].SerialNum
+	})
+	visible := make(map[int]bool)
+	selected := make(map[int][]string)
+	skipTo := 0
+	ended := false
+	for _, question := range sorted {
+		if ended || question.SerialNum < skipTo {
+			continue
+		}
+		if question.DisplaySerialNum != 0 && !containsString(selected[question.DisplaySerialNum], question.DisplayOption) {
+			continue
+		}
+		visible[question.ID] = true
+		answer := answers[question.ID]
+		if answer == "" || (question.QuestionType != 1 && question.QuestionType != 2) {
+			continue
+		}
+		selected[question.SerialNum] = strings.Split(answer, mongodbService.OptionSeparator)
+		for _, option := range options[question.ID] {
+			if option.SkipTo == 0 || !containsString(selected[question.SerialNum], option.Content) {
+				continue
+			}
+			if option.SkipTo == -1 {
+				ended = true
+			} else if option.SkipTo > skipTo {
+				skipTo = option.SkipTo
+			}
+		}
+	}
+	return visible
+}
+
+func containsString(arr []string, str string) bool {
+	for _, a := range arr {
+		if a == str {
+			return true
+		}
+	}
+	return false
+}
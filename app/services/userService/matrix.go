@@ -0,0 +1,72 @@
+package userService
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"strings"
+)
+
+// 矩阵题每行相当于一道选择题，9为每行单选，10为每行多选
+func IsMatrixQuestion(questionType int) bool {
+	return questionType == 9 || questionType == 10
+}
+
+// 拆分以分隔符连接的矩阵题行标题
+func SplitMatrixRows(rows string) []string {
+	return splitJoined(rows)
+}
+
+// 将矩阵题的答案转为文本，每行一条
+func FormatMatrixAnswer(matrix []mongodbService.MatrixAnswer) string {
+	lines := make([]string, 0)
+	for _, m := range matrix {
+		lines = append(lines, m.Row+"："+m.Content)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 矩阵题的行须为题目中的行且不重复，每行答案只能是题目的选项，必填时每行都须作答
+func checkMatrixAnswer(question models.Question, options []models.Option, matrix []mongodbService.MatrixAnswer) error {
+	if len(matrix) == 0 {
+		return newAnswerError(question, apiException.OptionError, "矩阵题须按行作答")
+	}
+	rows := make(map[string]bool)
+	for _, row := range SplitMatrixRows(question.MatrixRows) {
+		rows[row] = true
+	}
+	contents := make(map[string]bool)
+	for _, option := range options {
+		contents[option.Content] = true
+	}
+	answered := make(map[string]bool)
+	for _, m := range matrix {
+		if !rows[m.Row] {
+			return newAnswerError(question, apiException.OptionError, "矩阵题的行不存在："+m.Row)
+		}
+		if answered[m.Row] {
+			return newAnswerError(question, apiException.OptionError, "矩阵题的行重复作答："+m.Row)
+		}
+		answered[m.Row] = true
+		selected := []string{m.Content}
+		if question.QuestionType == 10 {
+			selected = strings.Split(m.Content, mongodbService.OptionSeparator)
+		} else if strings.Contains(m.Content, mongodbService.OptionSeparator) {
+			return newAnswerError(question, apiException.OptionError, "矩阵单选题每行只能选择一个选项："+m.Row)
+		}
+		seen := make(map[string]bool)
+		for _, s := range selected {
+			if !contents[s] {
+				return newAnswerError(question, apiException.OptionError, "选项不存在："+s)
+			}
+			if seen[s] {
+				return newAnswerError(question, apiException.OptionError, "选项重复")
+			}
+			seen[s] = true
+		}
+	}
+	if question.Required && len(answered) < len(rows) {
+		return newAnswerError(question, apiException.RequiredError, "矩阵题每行都须作答")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package userService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"sort"
+	"strings"
+)
+
+// 拆分以分隔符连接的参考答案
+func SplitAcceptedAnswers(acceptedAnswers string) []string {
+	return splitJoined(acceptedAnswers)
+}
+
+// 按分隔符拆分并去掉空白项
+func splitJoined(joined string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(joined, mongodbService.OptionSeparator) {
+		if strings.TrimSpace(item) != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 测验模式下为答卷评分，返回得分和满分。
+// 单选题和多选题选中的选项与正确选项完全一致得分，填空题和简答题与任一参考答案一致(忽略首尾空白和大小写)得分
+func GradeAnswers(questions []models.Question, data []QuestionsList) (int, int, error) {
+	answers := make(map[int]string)
+	for _, q := range data {
+		answers[q.QuestionID] = q.Answer
+	}
+	score, fullScore := 0, 0
+	for _, question := range questions {
+		if question.Score <= 0 {
+			continue
+		}
+		fullScore += question.Score
+		answer, ok := answers[question.ID]
+		if !ok || answer == "" {
+			continue
+		}
+		correct := false
+		switch question.QuestionType {
+		case 1, 2:
+			options, err := GetOptionsByQuestionID(question.ID)
+			if err != nil {
+				return 0, 0, err
+			}
+			correct = isCorrectChoice(options, answer)
+		case 3, 4:
+			for _, accepted := range SplitAcceptedAnswers(question.AcceptedAnswers) {
+				if strings.EqualFold(strings.TrimSpace(accepted), strings.TrimSpace(answer)) {
+					correct = true
+					break
+				}
+			}
+		}
+		if correct {
+			score += question.Score
+		}
+	}
+	return score, fullScore, nil
+}
+
+func isCorrectChoice(options []models.Option, answer string) bool {
+	correct := make([]string, 0)
+	for _, option := range options {
+		if option.IsCorrect {
+			correct = append(correct, option.Content)
+		}
+	}
+	if len(correct) == 0 {
+		return false
+	}
+	chosen := strings.Split(answer, mongodbService.OptionSeparator)
+	if len(chosen) != len(correct) {
+		return false
+	}
+	sort.Strings(correct)
+	sort.Strings(chosen)
+	for i := range correct {
+		if correct[i] != chosen[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package userService
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/config/database"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrSurveyFull = errors.New("问卷名额已满")
+
+type chosenOption struct {
+	question models.Question
+	option   models.Option
+}
+
+// 占用一份答卷数和所选选项的名额，任一名额不足时整体回滚，达到最大答卷数时自动结束问卷。
+// 选项名额不足时返回*AnswerError
+func AcquireQuota(survey models.Survey, data []QuestionsList) error {
+	chosen, err := getChosenOptions(survey.ID, data)
+	if err != nil {
+		return err
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// 以条件更新保证并发提交时不会超出名额
+		result := tx.Model(&models.Survey{}).Where("id = ? AND (max_responses = 0 OR num < max_responses)", survey.ID).
+			Update("num", gorm.Expr("num + ?", 1))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSurveyFull
+		}
+		for _, c := range chosen {
+			result = tx.Model(&models.Option{}).Where("id = ? AND (quota = 0 OR used < quota)", c.option.ID).
+				Update("used", gorm.Expr("used + ?", 1))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return newAnswerError(c.question, apiException.OptionFullError, "选项名额已满："+c.option.Content)
+			}
+		}
+		return tx.Model(&models.Survey{}).
+			Where("id = ? AND max_responses > 0 AND num >= max_responses", survey.ID).
+			Where("status IN ?", []int{models.SurveyOpen, models.SurveyScheduled}).
+			Update("status", models.SurveyClosed).Error
+	})
+}
+
+// 释放答卷占用的名额，用于提交失败或删除答卷
+func ReleaseQuota(survey models.Survey, data []QuestionsList) error {
+	chosen, err := getChosenOptions(survey.ID, data)
+	if err != nil {
+		return err
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Survey{}).Where("id = ? AND num > 0", survey.ID).Update("num", gorm.Expr("num - ?", 1)).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range chosen {
+			err = tx.Model(&models.Option{}).Where("id = ? AND used > 0", c.option.ID).Update("used", gorm.Expr("used - ?", 1)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 获取答卷中选择题选中的选项，"其他"填写的内容不占用名额
+func getChosenOptions(sid int, data []QuestionsList) ([]chosenOption, error) {
+	questions, err := GetQuestionsBySurveyID(sid)
+	if err != nil {
+		return nil, err
+	}
+	questionMap := make(map[int]models.Question)
+	for _, question := range questions {
+		questionMap[question.ID] = question
+	}
+	chosen := make([]chosenOption, 0)
+	for _, q := range data {
+		question, ok := questionMap[q.QuestionID]
+		if !ok || (question.QuestionType != 1 && question.QuestionType != 2) || q.Answer == "" {
+			continue
+		}
+		options, err := GetOptionsByQuestionID(question.ID)
+		if err != nil {
+			return nil, err
+		}
+		contents := strings.Split(q.Answer, mongodbService.OptionSeparator)
+		for _, option := range options {
+			if containsString(contents, option.Content) {
+				chosen = append(chosen, chosenOption{question: question, option: option})
+			}
+		}
+	}
+	return chosen, nil
+}
+
+// 剩余名额，不限时返回-1
+func GetRemaining(limit int, used int) int {
+	if limit == 0 {
+		return -1
+	}
+	if used > limit {
+		return 0
+	}
+	return limit - used
+}
@@ -0,0 +1,41 @@
+package userService
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"fmt"
+)
+
+// 需要排出的名次数，未设置或超过选项数时为全部选项
+func GetRankCount(question models.Question, options []models.Option) int {
+	if question.RankLimit <= 0 || question.RankLimit > len(options) {
+		return len(options)
+	}
+	return question.RankLimit
+}
+
+// 排序题须恰好排出要求的名次数，每项都是题目的选项且不重复
+func checkRankingAnswer(question models.Question, options []models.Option, ranking []string) error {
+	if len(ranking) == 0 {
+		return newAnswerError(question, apiException.OptionError, "排序题须按名次提交选项")
+	}
+	contents := make(map[string]bool)
+	for _, option := range options {
+		contents[option.Content] = true
+	}
+	count := GetRankCount(question, options)
+	if len(ranking) != count {
+		return newAnswerError(question, apiException.OptionError, fmt.Sprintf("排序题须排出%d项", count))
+	}
+	seen := make(map[string]bool)
+	for _, r := range ranking {
+		if !contents[r] {
+			return newAnswerError(question, apiException.OptionError, "选项不存在："+r)
+		}
+		if seen[r] {
+			return newAnswerError(question, apiException.OptionError, "选项重复："+r)
+		}
+		seen[r] = true
+	}
+	return nil
+}
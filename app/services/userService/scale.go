@@ -0,0 +1,40 @@
+package userService
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"fmt"
+	"strconv"
+)
+
+// 评分、NPS和量表题均以数值作答
+func IsScaleQuestion(questionType int) bool {
+	return questionType == 6 || questionType == 7 || questionType == 8
+}
+
+// 获取量表题的取值范围，NPS固定为0到10，其余题型未设置时默认为1到5
+func GetScaleRange(questionType int, min int, max int, step int) (int, int, int) {
+	if questionType == 7 {
+		return 0, 10, 1
+	}
+	if min == 0 && max == 0 {
+		min, max = 1, 5
+	}
+	if step == 0 {
+		step = 1
+	}
+	return min, max, step
+}
+
+// 量表题答案须为范围内且符合步长的整数
+func checkScaleAnswer(question models.Question, answer string) error {
+	value, err := strconv.Atoi(answer)
+	if err != nil {
+		return newAnswerError(question, apiException.ScaleError, "评分须为整数")
+	}
+	min, max, step := GetScaleRange(question.QuestionType, question.ScaleMin, question.ScaleMax, question.ScaleStep)
+	if value < min || value > max || (value-min)%step != 0 {
+		return newAnswerError(question, apiException.ScaleError, fmt.Sprintf("评分须在%d到%d之间，步长为%d", min, max, step))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package userService
+
+import (
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+)
+
+// 以填写者标识和对象ID生成随机数，同一填写者每次得到相同的顺序
+func newShuffleRand(seed string, id int) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%s:%d", seed, id)))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// 按填写者打乱题目顺序，只在连续的同组题目内打乱，未开启时保持原顺序
+func ShuffleQuestions(survey models.Survey, questions []models.Question, seed string) []models.Question {
+	shuffled := make([]models.Question, len(questions))
+	copy(shuffled, questions)
+	if !survey.ShuffleQuestions {
+		return shuffled
+	}
+	r := newShuffleRand(seed, survey.ID)
+	for start := 0; start < len(shuffled); {
+		end := start + 1
+		for end < len(shuffled) && shuffled[end].Block == shuffled[start].Block {
+			end++
+		}
+		block := shuffled[start:end]
+		r.Shuffle(len(block), func(i, j int) {
+			block[i], block[j] = block[j], block[i]
+		})
+		start = end
+	}
+	return shuffled
+}
+
+// 按填写者打乱选项顺序，固定在末尾的选项保持原有相对顺序
+func ShuffleOptions(question models.Question, options []models.Option, seed string) []models.Option {
+	if !question.ShuffleOptions {
+		return options
+	}
+	shuffled := make([]models.Option, 0, len(options))
+	pinned := make([]models.Option, 0)
+	for _, option := range options {
+		if option.PinLast {
+			pinned = append(pinned, option)
+		} else {
+			shuffled = append(shuffled, option)
+		}
+	}
+	r := newShuffleRand(seed, question.ID)
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return append(shuffled, pinned...)
+}
+
+// 获取填写者看到的题目和选项顺序，问卷和题目均未开启打乱时返回nil
+func GetPresentedOrder(survey models.Survey, questions []models.Question, seed string) (*mongodbService.PresentedOrder, error) {
+	order := &mongodbService.PresentedOrder{Questions: make([]int, 0), Options: make([]mongodbService.OptionOrder, 0)}
+	shuffled := survey.ShuffleQuestions
+	for _, question := range ShuffleQuestions(survey, questions, seed) {
+		order.Questions = append(order.Questions, question.ID)
+		if !question.ShuffleOptions {
+			continue
+		}
+		shuffled = true
+		options, err := GetOptionsByQuestionID(question.ID)
+		if err != nil {
+			return nil, err
+		}
+		optionOrder := mongodbService.OptionOrder{QuestionID: question.ID, Options: make([]int, 0)}
+		for _, option := range ShuffleOptions(question, options, seed) {
+			optionOrder.Options = append(optionOrder.Options, option.SerialNum)
+		}
+		order.Options = append(order.Options, optionOrder)
+	}
+	if !shuffled {
+		return nil, nil
+	}
+	return order, nil
+}
@@ -1,12 +1,14 @@
 package userService
 
 import (
+	"QA-System/app/apiException"
 	"QA-System/app/models"
 	"QA-System/app/services/mongodbService"
 	"QA-System/config/database"
+	"strings"
 	"time"
 
-	"gorm.io/gorm"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Option struct {
@@ -23,15 +25,17 @@ type Question struct {
 	Img          string   `json:"img"`           //图片
 	Required     bool     `json:"required"`      //是否必填
 	Unique       bool     `json:"unique"`        //是否唯一
-	QuestionType int      `json:"question_type"` //问题类型 1单选2多选3填空4简答5图片
+	QuestionType int      `json:"question_type"` //问题类型 1单选2多选3填空4简答5图片6评分7NPS8量表9矩阵单选10矩阵多选11排序
 	Reg          string   `json:"reg"`           //正则表达式
 	Options      []Option `json:"options"`       //选项
 }
 
 type QuestionsList struct {
-	QuestionID int    `json:"question_id" binding:"required"`
-	SerialNum  int    `json:"serial_num"`
-	Answer     string `json:"answer"`
+	QuestionID int                           `json:"question_id" binding:"required"`
+	SerialNum  int                           `json:"serial_num"`
+	Answer     string                        `json:"answer"`
+	Matrix     []mongodbService.MatrixAnswer `json:"matrix"`  //矩阵题按行作答
+	Ranking    []string                      `json:"ranking"` //排序题按名次排列的选项内容
 }
 
 func GetSurveyByID(id int) (models.Survey, error) {
@@ -40,9 +44,34 @@ func GetSurveyByID(id int) (models.Survey, error) {
 	return survey, err
 }
 
+// 判断问卷当前是否可以填写，不可填写时返回对应的错误
+func CheckSurveyOpen(survey models.Survey) *apiException.Error {
+	now := time.Now()
+	switch survey.Status {
+	case models.SurveyOpen:
+	case models.SurveyScheduled:
+		// 定时任务尚未执行时以开始时间为准
+		if survey.StartTime != nil && survey.StartTime.After(now) {
+			return apiException.SurveyNotStart
+		}
+	case models.SurveyPaused:
+		return apiException.SurveyPaused
+	case models.SurveyClosed, models.SurveyArchived:
+		return apiException.TimeBeyondError
+	default:
+		return apiException.SurveyNotOpen
+	}
+	if !survey.Deadline.IsZero() && survey.Deadline.Before(now) {
+		return apiException.TimeBeyondError
+	}
+	return nil
+}
+
 func GetQuestionsBySurveyID(id int) ([]models.Question, error) {
 	var questions []models.Question
-	err := database.DB.Where("survey_id = ?", id).Find(&questions).Error
+	// 只获取问卷当前版本的问题
+	version := database.DB.Model(&models.Survey{}).Select("version").Where("id = ?", id)
+	err := database.DB.Where("survey_id = ? AND version = (?)", id, version).Order("serial_num").Find(&questions).Error
 	return questions, err
 }
 
@@ -58,16 +87,21 @@ func GetQuestionByID(id int) (models.Question, error) {
 	return question, err
 }
 
-func CheckUnique(sid int, qid int, serial_num int, content string) (bool, error) {
+// 判断答案在问卷所有版本的同一题目中是否唯一
+func CheckUnique(sid int, question models.Question, content string) (bool, error) {
+	questionIDs, err := getVersionQuestionIDs(question)
+	if err != nil {
+		return false, err
+	}
 	var answerSheets []mongodbService.AnswerSheet
-	answerSheets,_, err := mongodbService.GetAnswerSheetBySurveyID(sid,0,0)
+	answerSheets,_, err = mongodbService.GetAnswerSheetBySurveyID(sid,0,0,mongodbService.AnswerFilter{})
 	if err != nil {
 		return false, err
 	}
 
 	for _, answerSheet := range answerSheets {
 		for _, answer := range answerSheet.Answers {
-			if answer.QuestionID == qid && answer.SerialNum == serial_num && answer.Content == content {
+			if questionIDs[answer.QuestionID] && answer.Content == content {
 				return false, nil
 			}
 		}
@@ -75,24 +109,51 @@ func CheckUnique(sid int, qid int, serial_num int, content string) (bool, error)
 	return true, nil
 }
 
-func SubmitSurvey(sid int, data []QuestionsList) error {
+// 获取与题目标识相同的各版本问题ID，未设置标识的题目只对应自身
+func getVersionQuestionIDs(question models.Question) (map[int]bool, error) {
+	questionIDs := map[int]bool{question.ID: true}
+	if question.StableID == "" {
+		return questionIDs, nil
+	}
+	var ids []int
+	err := database.DB.Model(&models.Question{}).Where("survey_id = ? AND stable_id = ?", question.SurveyID, question.StableID).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		questionIDs[id] = true
+	}
+	return questionIDs, nil
+}
+
+func SubmitSurvey(sid int, version int, score int, order *mongodbService.PresentedOrder, data []QuestionsList) error {
 	var answerSheet mongodbService.AnswerSheet
+	answerSheet.ID = primitive.NewObjectID()
 	answerSheet.SurveyID = sid
+	answerSheet.Version = version
+	answerSheet.Score = score
+	answerSheet.Order = order
 	answerSheet.Time = time.Now().Format("2006-01-02 15:04:05")
 	for _, q := range data {
 		var answer mongodbService.Answer
 		answer.QuestionID = q.QuestionID
 		answer.SerialNum = q.SerialNum
 		answer.Content = q.Answer
+		if len(q.Matrix) > 0 {
+			answer.Content = FormatMatrixAnswer(q.Matrix)
+			answer.Matrix = q.Matrix
+		}
+		if len(q.Ranking) > 0 {
+			answer.Content = strings.Join(q.Ranking, mongodbService.OptionSeparator)
+			answer.Ranking = q.Ranking
+		}
 		answerSheet.Answers = append(answerSheet.Answers, answer)
 	}
 	err := mongodbService.SaveAnswerSheet(answerSheet)
 	if err != nil {
 		return err
 	}
-	err=database.DB.Model(&models.Survey{}).Where("id = ?", sid).Update("num", gorm.Expr("num + ?", 1)).Error
-	if err != nil {
-		return err
-	}
+	// 答卷已保存，推送失败不影响提交结果
+	_ = PublishSubmission(answerSheet)
 	return nil
 }
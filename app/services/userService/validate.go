@@ -0,0 +1,173 @@
+package userService
+
+import (
+	"QA-System/app/apiException"
+	"QA-System/app/models"
+	"QA-System/app/services/mongodbService"
+	"QA-System/config/config"
+	"regexp"
+	"strings"
+)
+
+// 答卷校验失败时返回的错误，指明出错的问题和原因
+type AnswerError struct {
+	QuestionID int                 `json:"question_id"`
+	SerialNum  int                 `json:"serial_num"`
+	Reason     string              `json:"reason"`
+	Err        *apiException.Error `json:"-"`
+}
+
+func (e *AnswerError) Error() string {
+	return e.Reason
+}
+
+func newAnswerError(question models.Question, err *apiException.Error, reason string) *AnswerError {
+	return &AnswerError{
+		QuestionID: question.ID,
+		SerialNum:  question.SerialNum,
+		Reason:     reason,
+		Err:        err,
+	}
+}
+
+// 按题型校验答卷中的每个答案，被跳过的问题允许缺省
+func CheckAnswers(questions []models.Question, data []QuestionsList) error {
+	questionMap := make(map[int]models.Question)
+	for _, question := range questions {
+		questionMap[question.ID] = question
+	}
+	answers := make(map[int]string)
+	matrices := make(map[int][]mongodbService.MatrixAnswer)
+	rankings := make(map[int][]string)
+	answered := make(map[int]bool)
+	for _, q := range data {
+		question, ok := questionMap[q.QuestionID]
+		if !ok {
+			return &AnswerError{QuestionID: q.QuestionID, SerialNum: q.SerialNum, Reason: "问题不属于该问卷", Err: apiException.QuestionNotMatch}
+		}
+		if answered[q.QuestionID] {
+			return newAnswerError(question, apiException.QuestionNotMatch, "问题重复作答")
+		}
+		answered[q.QuestionID] = true
+		if question.SerialNum != q.SerialNum {
+			return newAnswerError(question, apiException.QuestionNotMatch, "问题序号不一致")
+		}
+		answers[q.QuestionID] = q.Answer
+		if len(q.Matrix) > 0 {
+			if !IsMatrixQuestion(question.QuestionType) {
+				return newAnswerError(question, apiException.QuestionNotMatch, "该问题不是矩阵题")
+			}
+			answers[q.QuestionID] = FormatMatrixAnswer(q.Matrix)
+			matrices[q.QuestionID] = q.Matrix
+		}
+		if len(q.Ranking) > 0 {
+			if question.QuestionType != 11 {
+				return newAnswerError(question, apiException.QuestionNotMatch, "该问题不是排序题")
+			}
+			answers[q.QuestionID] = strings.Join(q.Ranking, mongodbService.OptionSeparator)
+			rankings[q.QuestionID] = q.Ranking
+		}
+	}
+	options := make(map[int][]models.Option)
+	for _, question := range questions {
+		if question.QuestionType != 1 && question.QuestionType != 2 && !IsMatrixQuestion(question.QuestionType) && question.QuestionType != 11 {
+			continue
+		}
+		questionOptions, err := GetOptionsByQuestionID(question.ID)
+		if err != nil {
+			return err
+		}
+		options[question.ID] = questionOptions
+	}
+	visible := GetVisibleQuestions(questions, options, answers)
+	for _, question := range questions {
+		answer := answers[question.ID]
+		if !visible[question.ID] {
+			if answer != "" {
+				return newAnswerError(question, apiException.QuestionSkipped, "该问题已被跳过，无需作答")
+			}
+			continue
+		}
+		if answer == "" {
+			if question.Required {
+				return newAnswerError(question, apiException.RequiredError, "必填问题未填写")
+			}
+			continue
+		}
+		if IsMatrixQuestion(question.QuestionType) {
+			if err := checkMatrixAnswer(question, options[question.ID], matrices[question.ID]); err != nil {
+				return err
+			}
+			continue
+		}
+		if question.QuestionType == 11 {
+			if err := checkRankingAnswer(question, options[question.ID], rankings[question.ID]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkAnswer(question, options[question.ID], answer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAnswer(question models.Question, options []models.Option, answer string) error {
+	switch question.QuestionType {
+	case 1, 2:
+		return checkChoiceAnswer(question, options, answer)
+	case 3, 4:
+		if question.Reg == "" {
+			return nil
+		}
+		reg, err := regexp.Compile(question.Reg)
+		if err != nil {
+			return err
+		}
+		if !reg.MatchString(answer) {
+			return newAnswerError(question, apiException.RegError, "填写内容与格式要求不匹配")
+		}
+	case 5:
+		urlHost := config.Config.GetString("url.host")
+		if !strings.HasPrefix(answer, urlHost+"/static/") {
+			return newAnswerError(question, apiException.ImgAnswerError, "图片须通过上传接口上传")
+		}
+	case 6, 7, 8:
+		return checkScaleAnswer(question, answer)
+	}
+	return nil
+}
+
+// 选择题答案只能是问题的选项内容，开启其他选项时允许一个自定义内容
+func checkChoiceAnswer(question models.Question, options []models.Option, answer string) error {
+	contents := make(map[string]bool)
+	for _, option := range options {
+		contents[option.Content] = true
+	}
+	selected := []string{answer}
+	if question.QuestionType == 2 {
+		selected = strings.Split(answer, mongodbService.OptionSeparator)
+	} else if strings.Contains(answer, mongodbService.OptionSeparator) {
+		return newAnswerError(question, apiException.OptionError, "单选题只能选择一个选项")
+	}
+	seen := make(map[string]bool)
+	other := false
+	for _, s := range selected {
+		if s == "" {
+			return newAnswerError(question, apiException.OptionError, "选项内容为空")
+		}
+		if seen[s] {
+			return newAnswerError(question, apiException.OptionError, "选项重复")
+		}
+		seen[s] = true
+		if contents[s] {
+			continue
+		}
+		if !question.OtherOption || other {
+			return newAnswerError(question, apiException.OptionError, "选项不存在："+s)
+		}
+		other = true
+	}
+	return nil
+}
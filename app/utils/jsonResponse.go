@@ -21,3 +21,12 @@ func JsonSuccessResponse(c *gin.Context, data interface{}) {
 func JsonErrorResponse(c *gin.Context, err *apiException.Error) {
 	JsonResponse(c, http.StatusOK, err.Code, err.Msg, nil)
 }
+
+func JsonErrorResponseWithData(c *gin.Context, err *apiException.Error, data interface{}) {
+	JsonResponse(c, http.StatusOK, err.Code, err.Msg, data)
+}
+
+// 以错误对应的HTTP状态码返回，用于权限不足等需要区分状态码的场景
+func JsonErrorResponseWithStatus(c *gin.Context, err *apiException.Error) {
+	JsonResponse(c, err.StatusCode, err.Code, err.Msg, nil)
+}
@@ -7,11 +7,25 @@ import (
 )
 
 func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	// 协作者权限分级前，所有协作者都可以管理协作者
+	hasRole := db.Migrator().HasColumn(&models.Manage{}, "role")
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.Survey{},
 		&models.Question{},
 		&models.Option{},
 		&models.Manage{},
+		&models.Token{},
 	)
+	if err != nil {
+		return err
+	}
+	if !hasRole {
+		err = db.Model(&models.Manage{}).Where("1 = 1").Update("role", models.PermissionManage).Error
+		if err != nil {
+			return err
+		}
+	}
+	// 开始时间改为可空后，未设置的零值改为NULL
+	return db.Model(&models.Survey{}).Where("start_time < ?", "1000-01-01").Update("start_time", nil).Error
 }
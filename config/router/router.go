@@ -4,6 +4,7 @@ import (
 	"QA-System/app/controllers/adminController"
 	"QA-System/app/controllers/userController"
 	"QA-System/app/midwares"
+	"QA-System/app/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,23 +22,51 @@ func Init(r *gin.Engine) {
 			user.POST("/submit", userController.SubmitSurvey)
 			user.GET("/get", userController.GetSurvey)
 			user.POST("/upload", userController.UploadImg)
+			user.POST("/draft", userController.SaveDraft)
+			user.GET("/draft", userController.GetDraft)
 		}
 		admin := api.Group("/admin", midwares.CheckLogin)
 		{
-			admin.POST("/create", adminController.CreateSurvey)
-			admin.PUT("/update/status", adminController.UpdateSurveyStatus)
-			admin.PUT("/update/questions", adminController.UpdateSurvey)
-			admin.GET("/list/answers", adminController.GetSurveyAnswers)
-			admin.DELETE("/delete", adminController.DeleteSurvey)
+			admin.POST("/create", midwares.CheckAdminType(models.AdminTypeNormal, models.AdminTypeSuper), adminController.CreateSurvey)
+			admin.PUT("/update/status", midwares.CheckSurveyPermission(models.PermissionEdit), adminController.UpdateSurveyStatus)
+			admin.PUT("/update/questions", midwares.CheckSurveyPermission(models.PermissionEdit), adminController.UpdateSurvey)
+			admin.PUT("/update/limit", midwares.CheckSurveyPermission(models.PermissionEdit), adminController.UpdateSurveyLimit)
+			admin.PUT("/update/shuffle", midwares.CheckSurveyPermission(models.PermissionEdit), adminController.UpdateSurveyShuffle)
+			admin.GET("/list/answers", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetSurveyAnswers)
+			admin.GET("/response/list", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetResponses)
+			admin.GET("/response", midwares.CheckResponsePermission(models.PermissionView), adminController.GetResponse)
+			admin.DELETE("/response", midwares.CheckResponsePermission(models.PermissionEdit), adminController.DeleteResponse)
+			admin.GET("/statistics", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetSurveyStatistics)
+			admin.GET("/crosstab", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetCrosstab)
+			admin.GET("/crosstab/download", midwares.RequireScope(models.ScopeExport), midwares.CheckSurveyPermission(models.PermissionExport), adminController.DownloadCrosstab)
+			admin.GET("/text", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetTextStatistics)
+			admin.GET("/live", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetLiveFeed)
+			admin.PUT("/quiz", midwares.CheckSurveyPermission(models.PermissionEdit), adminController.UpdateSurveyQuiz)
+			admin.GET("/quiz/statistics", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetQuizStatistics)
+			admin.GET("/quiz/ranking", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetQuizRanking)
+			admin.DELETE("/delete", midwares.CheckSurveyPermission(models.PermissionOwner), adminController.DeleteSurvey)
+			admin.POST("/clone", midwares.CheckAdminType(models.AdminTypeNormal, models.AdminTypeSuper), midwares.CheckSurveyPermission(models.PermissionView), adminController.CloneSurvey)
+			admin.POST("/import", midwares.CheckAdminType(models.AdminTypeNormal, models.AdminTypeSuper), adminController.ImportSurvey)
+			admin.GET("/export", midwares.RequireScope(models.ScopeExport), midwares.CheckSurveyPermission(models.PermissionView), adminController.ExportSurvey)
 
-			admin.POST("/permission/create", adminController.CreatrPermission)
-			admin.DELETE("/permission/delete", adminController.DeletePermission)
+			admin.PUT("/template", midwares.CheckSurveyPermission(models.PermissionOwner), adminController.UpdateSurveyTemplate)
+			admin.GET("/template/list", adminController.GetTemplates)
+			admin.POST("/template/use", midwares.CheckAdminType(models.AdminTypeNormal, models.AdminTypeSuper), adminController.UseTemplate)
+
+			admin.POST("/permission/create", midwares.CheckSurveyPermission(models.PermissionManage), adminController.CreatrPermission)
+			admin.PUT("/permission/update", midwares.CheckSurveyPermission(models.PermissionManage), adminController.UpdatePermission)
+			admin.DELETE("/permission/delete", midwares.CheckSurveyPermission(models.PermissionManage), adminController.DeletePermission)
+			admin.GET("/permission/list", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetPermissions)
 
 			admin.GET("/list/questions", adminController.GetAllSurvey)
-			admin.GET("/single/question", adminController.GetSurvey)
-			admin.GET("/download", adminController.DownloadFile)
+			admin.GET("/single/question", midwares.CheckSurveyPermission(models.PermissionView), adminController.GetSurvey)
+			admin.GET("/download", midwares.RequireScope(models.ScopeExport), midwares.CheckSurveyPermission(models.PermissionExport), adminController.DownloadFile)
+
+			admin.POST("/token", midwares.RequireSession, adminController.CreateToken)
+			admin.GET("/token/list", midwares.RequireSession, adminController.GetTokens)
+			admin.DELETE("/token", midwares.RequireSession, adminController.DeleteToken)
 
-			admin.GET("/log", adminController.GetLogMsg)
+			admin.GET("/log", midwares.CheckAdminType(models.AdminTypeSuper, models.AdminTypeAuditor), adminController.GetLogMsg)
 
 		}
 	}
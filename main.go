@@ -2,10 +2,12 @@ package main
 
 import (
 	"QA-System/app/midwares"
+	"QA-System/app/services/adminService"
 	"QA-System/config/database"
 	"QA-System/config/router"
 	"QA-System/config/session"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,12 +15,12 @@ import (
 func main() {
 	database.MysqlInit()
 	database.MongodbInit()
+	adminService.StartSurveyScheduler(time.Minute)
 	r := gin.Default()
 	r.Use(midwares.ErrHandler())
 	r.NoMethod(midwares.HandleNotFound)
 	r.NoRoute(midwares.HandleNotFound)
 	r.Static("/static", "./static")
-	r.Static("/xlsx", "./xlsx")
 	session.Init(r)
 	router.Init(r)
 	err := r.Run()